@@ -0,0 +1,671 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
+	"gopkg.in/yaml.v2"
+)
+
+// A manifest describes the desired JetStream setup of a context:
+//
+//	streams:
+//	  - name: ORDERS
+//	    subjects: ["orders.>"]
+//	    max_age: 24h
+//	consumers:
+//	  - stream: ORDERS
+//	    durable_name: PROCESSOR
+//	    ack_policy: explicit
+//	key_value:
+//	  - bucket: settings
+//	    history: 5
+//	object_stores:
+//	  - bucket: files
+//	    delete: true
+//
+// Entries use the keys of the stream and consumer editors. Keys left out of
+// an entry keep their current value when it is updated, "delete: true"
+// removes the entry from the server.
+type jsManifest struct {
+	Streams      []map[string]interface{} `json:"streams,omitempty" yaml:"streams,omitempty"`
+	Consumers    []map[string]interface{} `json:"consumers,omitempty" yaml:"consumers,omitempty"`
+	KeyValue     []map[string]interface{} `json:"key_value,omitempty" yaml:"key_value,omitempty"`
+	ObjectStores []map[string]interface{} `json:"object_stores,omitempty" yaml:"object_stores,omitempty"`
+
+	// replace makes entries the whole desired config, keys left out are reset
+	// to their server default instead of keeping their current value
+	replace bool
+}
+
+const (
+	applyCreate    = "create"
+	applyUpdate    = "update"
+	applyDelete    = "delete"
+	applyUnchanged = "unchanged"
+)
+
+// applyAction is one step of a plan. Actions with an error block the plan.
+type applyAction struct {
+	kind    string // stream, consumer, key_value or object_store
+	name    string
+	op      string
+	changes []configChange
+	err     error
+	run     func() error
+}
+
+type applyPlan struct {
+	actions []*applyAction
+}
+
+// bucketKind maps a KV or object store bucket onto its backing stream
+type bucketKind struct {
+	kind      string
+	prefix    string
+	durations []string
+	// bucket config keys that can be updated and their stream config key
+	streamKeys map[string]string
+	create     func(js nats.JetStreamContext, values map[string]interface{}) error
+	remove     func(js nats.JetStreamContext, bucket string) error
+}
+
+var kvBucketKind = bucketKind{
+	kind:      "key_value",
+	prefix:    "KV_",
+	durations: []string{"ttl"},
+	streamKeys: map[string]string{
+		"description":    "description",
+		"history":        "max_msgs_per_subject",
+		"ttl":            "max_age",
+		"max_bytes":      "max_bytes",
+		"max_value_size": "max_msg_size",
+		"storage":        "storage",
+		"num_replicas":   "num_replicas",
+		"compression":    "compression",
+		"placement":      "placement",
+		"republish":      "republish",
+	},
+	create: func(js nats.JetStreamContext, values map[string]interface{}) error {
+		var cfg nats.KeyValueConfig
+		if err := decodeConfig(values, &cfg); err != nil {
+			return err
+		}
+		_, err := js.CreateKeyValue(&cfg)
+		return err
+	},
+	remove: func(js nats.JetStreamContext, bucket string) error {
+		return js.DeleteKeyValue(bucket)
+	},
+}
+
+var objectBucketKind = bucketKind{
+	kind:      "object_store",
+	prefix:    "OBJ_",
+	durations: []string{"max_age"},
+	streamKeys: map[string]string{
+		"description":  "description",
+		"max_age":      "max_age",
+		"max_bytes":    "max_bytes",
+		"storage":      "storage",
+		"num_replicas": "num_replicas",
+		"compression":  "compression",
+		"placement":    "placement",
+		"metadata":     "metadata",
+	},
+	create: func(js nats.JetStreamContext, values map[string]interface{}) error {
+		var cfg nats.ObjectStoreConfig
+		if err := decodeConfig(values, &cfg); err != nil {
+			return err
+		}
+		_, err := js.CreateObjectStore(&cfg)
+		return err
+	},
+	remove: func(js nats.JetStreamContext, bucket string) error {
+		return js.DeleteObjectStore(bucket)
+	},
+}
+
+var bucketImmutableFields = map[string]string{
+	"storage": "the storage type can't be changed",
+}
+
+// manifestSections are the section names in manifest order
+var manifestSections = []string{"streams", "consumers", "key_value", "object_stores"}
+
+// sections maps the section names to their entries
+func (m *jsManifest) sections() map[string]*[]map[string]interface{} {
+	return map[string]*[]map[string]interface{}{
+		"streams":       &m.Streams,
+		"consumers":     &m.Consumers,
+		"key_value":     &m.KeyValue,
+		"object_stores": &m.ObjectStores,
+	}
+}
+
+func loadManifest(file string) (*jsManifest, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(b)
+}
+
+// parseManifest reads a YAML (or JSON) manifest
+func parseManifest(b []byte) (*jsManifest, error) {
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	root := convertToStringMap(raw)
+
+	m := &jsManifest{}
+	sections := m.sections()
+	for key, value := range root {
+		section, ok := sections[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown manifest section %q", key)
+		}
+		list, ok := value.([]interface{})
+		if !ok && value != nil {
+			return nil, fmt.Errorf("%s: expected a list", key)
+		}
+		for i, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s[%d]: expected an object", key, i)
+			}
+			*section = append(*section, entry)
+		}
+	}
+	return m, nil
+}
+
+// planManifest compares the manifest with the server and lists what applying
+// it changes. Creates and updates come first, consumers after their streams,
+// and deletes run in the reverse order.
+func planManifest(nc *nats.Conn, m *jsManifest) (*applyPlan, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	var upserts, deletes []*applyAction
+	add := func(a *applyAction) {
+		if a.op == applyDelete {
+			deletes = append([]*applyAction{a}, deletes...)
+		} else {
+			upserts = append(upserts, a)
+		}
+	}
+	for _, entry := range m.Streams {
+		add(planStream(nc, js, entry, m.replace))
+	}
+	for _, entry := range m.KeyValue {
+		add(planBucket(nc, js, kvBucketKind, entry, m.replace))
+	}
+	for _, entry := range m.ObjectStores {
+		add(planBucket(nc, js, objectBucketKind, entry, m.replace))
+	}
+	for _, entry := range m.Consumers {
+		add(planConsumer(nc, js, entry, m.replace))
+	}
+	return &applyPlan{actions: append(upserts, deletes...)}, nil
+}
+
+// takeDelete removes the delete marker from the entry
+func takeDelete(entry map[string]interface{}) (map[string]interface{}, bool, error) {
+	values := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		values[k] = v
+	}
+	del, ok := values["delete"]
+	if !ok {
+		return values, false, nil
+	}
+	delete(values, "delete")
+	b, isBool := del.(bool)
+	if !isBool {
+		return nil, false, fmt.Errorf("delete must be true or false")
+	}
+	return values, b, nil
+}
+
+func planStream(nc *nats.Conn, js nats.JetStreamContext, entry map[string]interface{}, replace bool) *applyAction {
+	name, _ := entry["name"].(string)
+	action := &applyAction{kind: "stream", name: name}
+	values, del, err := takeDelete(entry)
+	if err != nil {
+		action.err = err
+		return action
+	}
+	if name == "" {
+		action.err = fmt.Errorf("stream name is required")
+		return action
+	}
+
+	live, err := natsutil.StreamConfigRaw(nc, name)
+	exists := err == nil
+	if err != nil && !natsutil.IsNotFound(err) {
+		action.err = err
+		return action
+	}
+	if del {
+		return planDelete(action, exists, func() error { return js.DeleteStream(name) })
+	}
+
+	config, _, err := streamConfigFromValues(values)
+	if err != nil {
+		action.err = err
+		return action
+	}
+	if !exists {
+		action.op = applyCreate
+		action.run = func() error { return natsutil.CreateStreamRaw(nc, config) }
+		return action
+	}
+
+	if replace {
+		desired := withStreamDefaults(config)
+		stripServerMetadata(live)
+		return planUpdate(action, withStreamDefaults(live), desired, streamImmutableFields, func() error {
+			return natsutil.UpdateStreamRaw(nc, desired)
+		})
+	}
+	merged := copyConfigMap(live)
+	for k, v := range config {
+		merged[k] = v
+	}
+	return planUpdate(action, pickConfigKeys(live, config), config, streamImmutableFields, func() error {
+		return natsutil.UpdateStreamRaw(nc, merged)
+	})
+}
+
+func planConsumer(nc *nats.Conn, js nats.JetStreamContext, entry map[string]interface{}, replace bool) *applyAction {
+	stream, _ := entry["stream"].(string)
+	name, _ := entry["durable_name"].(string)
+	if name == "" {
+		name, _ = entry["name"].(string)
+	}
+	action := &applyAction{kind: "consumer", name: stream + "/" + name}
+	values, del, err := takeDelete(entry)
+	if err != nil {
+		action.err = err
+		return action
+	}
+	delete(values, "stream")
+	if stream == "" || name == "" {
+		action.err = fmt.Errorf("consumers need a stream and a durable_name or name")
+		return action
+	}
+
+	info, err := js.ConsumerInfo(stream, name)
+	exists := err == nil
+	if err != nil && !errors.Is(err, nats.ErrConsumerNotFound) && !errors.Is(err, nats.ErrStreamNotFound) {
+		action.err = err
+		return action
+	}
+	if del {
+		return planDelete(action, exists, func() error { return js.DeleteConsumer(stream, name) })
+	}
+
+	wire, config, err := consumerConfigFromValues(values)
+	if err != nil {
+		action.err = err
+		return action
+	}
+	// pause_until goes through the pause API after creating or updating
+	pauseUntil, setPause := wire["pause_until"]
+	pause := func() error {
+		if !setPause {
+			return nil
+		}
+		return pauseConsumer(nc, stream, name, pauseUntil)
+	}
+	if !exists {
+		action.op = applyCreate
+		action.run = func() error {
+			if _, err := js.AddConsumer(stream, config); err != nil {
+				return err
+			}
+			if pauseUntil == nil {
+				return nil
+			}
+			return pause()
+		}
+		return action
+	}
+
+	var live map[string]interface{}
+	liveBytes, _ := json.Marshal(info.Config)
+	json.Unmarshal(liveBytes, &live)
+	if setPause {
+		if live["pause_until"], err = livePauseUntil(nc, stream, name); err != nil {
+			action.err = err
+			return action
+		}
+	}
+	current := pickConfigKeys(live, wire)
+	merged := copyConfigMap(live)
+	for k, v := range copyConfigMap(wire) {
+		merged[k] = v
+	}
+	if replace {
+		current = live
+		stripServerMetadata(current)
+		if _, ok := wire["name"]; !ok {
+			delete(current, "name") // exports leave out a name equal to the durable name
+		}
+		merged = copyConfigMap(wire)
+		merged["name"] = name
+	}
+	return planUpdate(action, current, wire, consumerImmutableFields, func() error {
+		var updated nats.ConsumerConfig
+		if err := decodeConfig(merged, &updated); err != nil {
+			return err
+		}
+		if _, err := js.UpdateConsumer(stream, &updated); err != nil {
+			return err
+		}
+		return pause()
+	})
+}
+
+// planBucket compares a bucket with its backing stream. Only the keys listed
+// in the bucket kind are compared, others only apply when creating it.
+func planBucket(nc *nats.Conn, js nats.JetStreamContext, kind bucketKind, entry map[string]interface{}, replace bool) *applyAction {
+	bucket, _ := entry["bucket"].(string)
+	action := &applyAction{kind: kind.kind, name: bucket}
+	values, del, err := takeDelete(entry)
+	if err != nil {
+		action.err = err
+		return action
+	}
+	if bucket == "" {
+		action.err = fmt.Errorf("bucket name is required")
+		return action
+	}
+
+	live, err := natsutil.StreamConfigRaw(nc, kind.prefix+bucket)
+	exists := err == nil
+	if err != nil && !natsutil.IsNotFound(err) {
+		action.err = err
+		return action
+	}
+	if del {
+		return planDelete(action, exists, func() error { return kind.remove(js, bucket) })
+	}
+
+	config := copyConfigMap(values)
+	if err := durationStringsToNanos(config, kind.durations); err != nil {
+		action.err = err
+		return action
+	}
+	if !exists {
+		action.op = applyCreate
+		action.run = func() error { return kind.create(js, config) }
+		return action
+	}
+
+	if replace {
+		stripServerMetadata(live)
+	}
+	// Express the live stream config in bucket terms
+	current := make(map[string]interface{})
+	desired := make(map[string]interface{})
+	for key, streamKey := range kind.streamKeys {
+		v, ok := config[key]
+		if !ok && !replace {
+			continue
+		}
+		desired[key] = v
+		current[key] = live[streamKey]
+		if key == "compression" {
+			current[key] = live[streamKey] == "s2"
+		}
+	}
+	merged := copyConfigMap(live)
+	for key, v := range desired {
+		if key == "compression" {
+			if b, _ := v.(bool); b {
+				v = "s2"
+			} else {
+				v = "none"
+			}
+		}
+		merged[kind.streamKeys[key]] = v
+	}
+	// The duplicate window can't be longer than the maximum age
+	if maxAge, ok := merged["max_age"].(float64); ok && maxAge > 0 {
+		if window, ok := merged["duplicate_window"].(float64); ok && window > maxAge {
+			merged["duplicate_window"] = maxAge
+		}
+	}
+	return planUpdate(action, current, desired, bucketImmutableFields, func() error {
+		return natsutil.UpdateStreamRaw(nc, merged)
+	})
+}
+
+func planDelete(action *applyAction, exists bool, run func() error) *applyAction {
+	if !exists {
+		action.op = applyUnchanged
+		return action
+	}
+	action.op = applyDelete
+	action.run = run
+	return action
+}
+
+func planUpdate(action *applyAction, live, desired map[string]interface{}, immutable map[string]string, run func() error) *applyAction {
+	action.changes = diffConfigs(live, desired, immutable)
+	if len(action.changes) == 0 {
+		action.op = applyUnchanged
+		return action
+	}
+	action.op = applyUpdate
+	for _, c := range action.changes {
+		if c.Immutable != "" {
+			action.err = fmt.Errorf("%s: %s", c.Path, c.Immutable)
+			return action
+		}
+	}
+	action.run = run
+	return action
+}
+
+// pickConfigKeys returns the values of m for the keys set in keys
+func pickConfigKeys(m, keys map[string]interface{}) map[string]interface{} {
+	picked := make(map[string]interface{}, len(keys))
+	for k := range keys {
+		if v, ok := m[k]; ok {
+			picked[k] = v
+		}
+	}
+	return picked
+}
+
+// decodeConfig converts a config map to its nats.go struct
+func decodeConfig(values map[string]interface{}, out interface{}) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+	return nil
+}
+
+// counts returns the number of actions per operation and how many are blocked
+func (p *applyPlan) counts() (map[string]int, int) {
+	counts := make(map[string]int)
+	blocked := 0
+	for _, a := range p.actions {
+		if a.err != nil {
+			blocked++
+			continue
+		}
+		counts[a.op]++
+	}
+	return counts, blocked
+}
+
+// pending reports whether applying the plan changes anything
+func (p *applyPlan) pending() bool {
+	for _, a := range p.actions {
+		if a.run != nil && a.err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// format renders the plan, with tview color tags when colored is set
+func (p *applyPlan) format(colored bool) string {
+	paint := func(color, s string) string {
+		if !colored {
+			return s
+		}
+		return "[" + color + "]" + tview.Escape(s) + "[-]"
+	}
+	escape := func(s string) string {
+		if !colored {
+			return s
+		}
+		return tview.Escape(s)
+	}
+	var sb strings.Builder
+	for _, a := range p.actions {
+		label := a.kind + " " + a.name
+		switch {
+		case a.err != nil:
+			sb.WriteString(paint("red", fmt.Sprintf("! %s: %v", label, a.err)) + "\n")
+		case a.op == applyCreate:
+			sb.WriteString(paint("green", "+ create "+label) + "\n")
+		case a.op == applyDelete:
+			sb.WriteString(paint("red", "- delete "+label) + "\n")
+		case a.op == applyUpdate:
+			sb.WriteString(paint("yellow", "~ update "+label) + "\n")
+		default:
+			sb.WriteString(paint("gray", "= "+label+" unchanged") + "\n")
+		}
+		for _, c := range a.changes {
+			sb.WriteString(escape(fmt.Sprintf("    %s: %s -> %s", c.Path, formatDiffValue(c.Path, c.Old), formatDiffValue(c.Path, c.New))) + "\n")
+		}
+	}
+
+	counts, blocked := p.counts()
+	sb.WriteString(fmt.Sprintf("\nPlan: %d to create, %d to update, %d to delete, %d unchanged",
+		counts[applyCreate], counts[applyUpdate], counts[applyDelete], counts[applyUnchanged]))
+	if blocked > 0 {
+		sb.WriteString(paint("red", fmt.Sprintf(", %d blocked", blocked)))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// execute runs the actions in order and stops at the first failure. done is
+// called after every action that ran.
+func (p *applyPlan) execute(done func(a *applyAction, err error)) error {
+	if _, blocked := p.counts(); blocked > 0 {
+		return fmt.Errorf("the plan has %d blocked action(s)", blocked)
+	}
+	for _, a := range p.actions {
+		if a.run == nil {
+			continue
+		}
+		err := a.run()
+		if err != nil {
+			logger.Error("Failed to %s %s %s: %v", a.op, a.kind, a.name, err)
+		} else {
+			logger.Info("Applied: %s %s %s", a.op, a.kind, a.name)
+		}
+		if done != nil {
+			done(a, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s %s: %v", a.op, a.kind, a.name, err)
+		}
+	}
+	return nil
+}
+
+// loadContexts reads the saved contexts for the command line commands
+func loadContexts() error {
+	configDir, err := ds.GetConfigDir()
+	if err != nil {
+		return err
+	}
+	return data.LoadFromDir(path.Join(configDir, "context"))
+}
+
+// runApplyCommand implements `natsdash apply -f manifest.yaml --context NAME [--confirm]`.
+// It returns the process exit code.
+func runApplyCommand(args []string) int {
+	flags := flag.NewFlagSet("apply", flag.ContinueOnError)
+	file := flags.String("f", "", "manifest file (YAML or JSON)")
+	ctxName := flags.String("context", "", "saved context to apply the manifest to")
+	confirm := flags.Bool("confirm", false, "apply the plan instead of only printing it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" || *ctxName == "" {
+		fmt.Fprintln(os.Stderr, "usage: natsdash apply -f manifest.yaml --context NAME [--confirm]")
+		return 2
+	}
+
+	manifest, err := loadManifest(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := loadContexts(); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load contexts:", err)
+		return 1
+	}
+	conn, closeConn, err := contextConn(*ctxName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to %s: %v\n", *ctxName, err)
+		return 1
+	}
+	defer closeConn()
+
+	plan, err := planManifest(conn, manifest)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(plan.format(false))
+	if _, blocked := plan.counts(); blocked > 0 {
+		fmt.Fprintln(os.Stderr, "Fix the blocked actions before applying.")
+		return 1
+	}
+	if !plan.pending() {
+		return 0
+	}
+	if !*confirm {
+		fmt.Println("Run again with --confirm to apply these changes.")
+		return 0
+	}
+
+	err = plan.execute(func(a *applyAction, err error) {
+		if err == nil {
+			fmt.Printf("%sd %s %s\n", strings.TrimSuffix(a.op, "e"), a.kind, a.name)
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Apply failed:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+)
+
+// ApplyPage plans a manifest against the current context and applies it,
+// like `natsdash apply`
+type ApplyPage struct {
+	*tview.Flex
+	Data      *ds.Data
+	app       *tview.Application
+	fileInput *tview.InputField
+	planView  *tview.TextView
+	footerTxt *tview.TextView
+	plan      *applyPlan
+}
+
+func NewApplyPage(app *tview.Application, data *ds.Data) *ApplyPage {
+	ap := &ApplyPage{
+		Flex: tview.NewFlex().SetDirection(tview.FlexRow),
+		app:  app,
+		Data: data,
+	}
+	ap.setupUI()
+	ap.setupInputCapture()
+	return ap
+}
+
+func (ap *ApplyPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back\n[/] Manifest File", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[r] Plan\n[Alt+Enter] Apply", tcell.ColorWhite), 0, 1, false)
+	ap.AddItem(headerRow, 4, 0, false)
+
+	ap.fileInput = tview.NewInputField().
+		SetLabel("Manifest: ").
+		SetPlaceholder("manifest.yaml").
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	ap.fileInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			ap.computePlan()
+		}
+		ap.app.SetFocus(ap.planView)
+	})
+	ap.fileInput.SetBorderPadding(0, 0, 1, 1)
+	ap.AddItem(ap.fileInput, 1, 0, false)
+
+	ap.planView = tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
+	ap.planView.SetBorder(true)
+	ap.planView.SetTitle("Plan")
+	ap.planView.SetBorderPadding(0, 0, 1, 1)
+	ap.AddItem(ap.planView, 0, 1, true)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	ap.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(ap.footerTxt, 0, 1, false)
+	ap.AddItem(footer, 3, 0, false)
+
+	ap.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (ap *ApplyPage) setupInputCapture() {
+	ap.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if ap.fileInput.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEsc:
+			ap.goBack()
+			return nil
+		case tcell.KeyEnter:
+			if event.Modifiers() == tcell.ModAlt {
+				ap.confirmApply()
+				return nil
+			}
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case '/':
+				ap.app.SetFocus(ap.fileInput)
+				return nil
+			case 'r', 'R':
+				ap.computePlan()
+				return nil
+			}
+		}
+		return event
+	})
+}
+
+func (ap *ApplyPage) redraw(ctx *ds.Context) {
+	ap.plan = nil
+	ap.planView.SetTitle("Plan for " + ctx.Name)
+	if ap.fileInput.GetText() == "" {
+		ap.planView.SetText("Enter the path of a manifest file to plan it against this context.")
+		ap.app.SetFocus(ap.fileInput)
+		return
+	}
+	ap.computePlan()
+}
+
+// computePlan loads the manifest and compares it with the server
+func (ap *ApplyPage) computePlan() {
+	ap.plan = nil
+	file := strings.TrimSpace(ap.fileInput.GetText())
+	manifest, err := loadManifest(file)
+	if err != nil {
+		ap.planView.SetText("")
+		ap.notify("Failed to load manifest: "+err.Error(), 5*time.Second, "error")
+		return
+	}
+
+	ap.planView.SetText("Planning...")
+	conn := ap.Data.CurrCtx.Conn
+	go func() {
+		plan, err := planManifest(conn, manifest)
+		ap.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ap.planView.SetText("")
+				ap.notify("Failed to plan manifest: "+err.Error(), 5*time.Second, "error")
+				return
+			}
+			ap.plan = plan
+			ap.planView.SetText(plan.format(true))
+			ap.planView.ScrollToBeginning()
+		})
+	}()
+}
+
+func (ap *ApplyPage) confirmApply() {
+	if ap.plan == nil {
+		ap.notify("Plan a manifest first", 3*time.Second, "error")
+		return
+	}
+	if _, blocked := ap.plan.counts(); blocked > 0 {
+		ap.notify("Fix the blocked actions before applying", 3*time.Second, "error")
+		return
+	}
+	if !ap.plan.pending() {
+		ap.notify("Nothing to apply", 3*time.Second, "info")
+		return
+	}
+
+	counts, _ := ap.plan.counts()
+	text := fmt.Sprintf("Apply the plan to %s?\n\n%d to create, %d to update, [red]%d to delete[-]",
+		tview.Escape(ap.Data.CurrCtx.Name), counts[applyCreate], counts[applyUpdate], counts[applyDelete])
+	plan := ap.plan
+	showConfirmModal("Apply manifest", text, func() {
+		go ap.executePlan(plan)
+	})
+}
+
+func (ap *ApplyPage) executePlan(plan *applyPlan) {
+	var progress strings.Builder
+	err := plan.execute(func(a *applyAction, err error) {
+		line := fmt.Sprintf("[green]%s %s %s: done[-]\n", a.op, a.kind, tview.Escape(a.name))
+		if err != nil {
+			line = fmt.Sprintf("[red]%s %s %s: %s[-]\n", a.op, a.kind, tview.Escape(a.name), tview.Escape(err.Error()))
+		}
+		progress.WriteString(line)
+		text := progress.String()
+		ap.app.QueueUpdateDraw(func() {
+			ap.planView.SetText(text)
+		})
+	})
+	ap.app.QueueUpdateDraw(func() {
+		ap.plan = nil
+		if err != nil {
+			ap.notify("Apply failed: "+err.Error(), 5*time.Second, "error")
+			return
+		}
+		logger.Info("Applied manifest %s to %s", ap.fileInput.GetText(), ap.Data.CurrCtx.Name)
+		ap.notify("Manifest applied, press r to plan again", 5*time.Second, "info")
+	})
+}
+
+func (ap *ApplyPage) goBack() {
+	pages.SwitchToPage("streamListPage")
+	_, b := pages.GetFrontPage()
+	b.(*StreamListPage).redraw(&ap.Data.CurrCtx)
+	ap.app.SetFocus(b)
+}
+
+func (ap *ApplyPage) notify(message string, duration time.Duration, logLevel string) {
+	ap.footerTxt.SetText(message)
+	ap.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		ap.footerTxt.SetText("")
+		ap.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
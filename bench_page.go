@@ -0,0 +1,552 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
+)
+
+const (
+	benchModeCore      = "Core"
+	benchModeJetStream = "JetStream (async acks)"
+)
+
+type BenchPage struct {
+	*tview.Flex
+	Data       *ds.Data
+	app        *tview.Application
+	form       *tview.Form
+	statsView  *tview.TextView
+	footerTxt  *tview.TextView
+	returnPage string
+	stopCh     chan struct{}
+	runningMu  sync.Mutex
+	result     *benchResult
+
+	published atomic.Int64
+	received  atomic.Int64
+	failed    atomic.Int64
+	ackLat    *latencyHistogram
+	e2eLat    *latencyHistogram
+}
+
+// benchResult is what gets saved to the results file
+type benchResult struct {
+	Context         string          `json:"context"`
+	Subject         string          `json:"subject"`
+	Mode            string          `json:"mode"`
+	Started         time.Time       `json:"started"`
+	Duration        string          `json:"duration"`
+	Messages        int             `json:"messages"`
+	MessageSize     int             `json:"message_size"`
+	Publishers      int             `json:"publishers"`
+	TargetRate      int             `json:"target_rate"`
+	Published       int64           `json:"published"`
+	Received        int64           `json:"received"`
+	Errors          int64           `json:"errors"`
+	MsgsPerSec      float64         `json:"msgs_per_sec"`
+	BytesPerSec     float64         `json:"bytes_per_sec"`
+	AckLatency      *latencySummary `json:"ack_latency,omitempty"`
+	EndToEndLatency *latencySummary `json:"end_to_end_latency,omitempty"`
+}
+
+type latencySummary struct {
+	Count int    `json:"count"`
+	Min   string `json:"min"`
+	P50   string `json:"p50"`
+	P99   string `json:"p99"`
+	P999  string `json:"p999"`
+	Max   string `json:"max"`
+}
+
+func NewBenchPage(app *tview.Application, data *ds.Data) *BenchPage {
+	bp := &BenchPage{
+		Flex:       tview.NewFlex().SetDirection(tview.FlexRow),
+		app:        app,
+		Data:       data,
+		returnPage: "natsPage",
+	}
+	bp.setupUI()
+	bp.setupInputCapture()
+	return bp
+}
+
+func (bp *BenchPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back  |  [Tab] Focus Next", tcell.ColorWhite), 0, 1, false)
+	bp.AddItem(headerRow, 3, 1, false)
+
+	// Benchmark options
+	bp.form = tview.NewForm()
+	bp.form.SetBorder(true)
+	bp.form.SetTitle("Publish Benchmark")
+	bp.form.AddInputField("Subject", "bench.natsdash", 0, nil, nil)
+	bp.form.AddDropDown("Mode", []string{benchModeCore, benchModeJetStream}, 0, nil)
+	bp.form.AddInputField("Messages", "100000", 12, tview.InputFieldInteger, nil)
+	bp.form.AddInputField("Message Size (bytes)", "128", 12, tview.InputFieldInteger, nil)
+	bp.form.AddInputField("Publishers", "1", 12, tview.InputFieldInteger, nil)
+	bp.form.AddInputField("Rate (msgs/sec, 0=max)", "0", 12, tview.InputFieldInteger, nil)
+	bp.form.AddCheckbox("Measure End-to-End Latency", false, nil)
+	bp.form.AddInputField("Results File", "natsdash-bench.json", 0, nil, nil)
+	bp.form.AddButton("Start", bp.startBench)
+	bp.form.AddButton("Stop", bp.stopBench)
+	bp.form.AddButton("Save Results", bp.saveResults)
+	bp.AddItem(bp.form, 21, 1, true)
+
+	// Live statistics
+	bp.statsView = tview.NewTextView()
+	bp.statsView.SetBorder(true)
+	bp.statsView.SetTitle("Results")
+	bp.AddItem(bp.statsView, 0, 1, false)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	bp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(bp.footerTxt, 0, 1, false)
+	bp.AddItem(footer, 3, 1, false)
+
+	bp.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (bp *BenchPage) setupInputCapture() {
+	bp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			bp.goBack()
+			return nil
+		}
+		return event
+	})
+}
+
+func (bp *BenchPage) redraw(ctx *ds.Context) {
+	bp.form.SetTitle("Publish Benchmark on " + ctx.Name)
+	bp.app.SetFocus(bp.form)
+	go bp.app.Draw()
+}
+
+func (bp *BenchPage) goBack() {
+	bp.stopBench()
+	pages.SwitchToPage(bp.returnPage)
+	_, b := pages.GetFrontPage()
+	if sp, ok := b.(*StreamListPage); ok {
+		sp.redraw(&bp.Data.CurrCtx)
+	}
+	bp.app.SetFocus(b)
+}
+
+func (bp *BenchPage) formInt(label string) int {
+	n, _ := strconv.Atoi(bp.form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+	return n
+}
+
+func (bp *BenchPage) startBench() {
+	bp.runningMu.Lock()
+	defer bp.runningMu.Unlock()
+	if bp.stopCh != nil {
+		bp.notify("A benchmark is already running", 3*time.Second, "warn")
+		return
+	}
+
+	subject := bp.form.GetFormItemByLabel("Subject").(*tview.InputField).GetText()
+	_, mode := bp.form.GetFormItemByLabel("Mode").(*tview.DropDown).GetCurrentOption()
+	count := bp.formInt("Messages")
+	size := bp.formInt("Message Size (bytes)")
+	publishers := bp.formInt("Publishers")
+	rate := bp.formInt("Rate (msgs/sec, 0=max)")
+	measureE2E := bp.form.GetFormItemByLabel("Measure End-to-End Latency").(*tview.Checkbox).IsChecked()
+
+	if !isValidSubject(subject) {
+		bp.notify("Invalid subject: "+subject, 3*time.Second, "error")
+		return
+	}
+	if count <= 0 || publishers <= 0 {
+		bp.notify("Messages and publishers must be greater than zero", 3*time.Second, "error")
+		return
+	}
+	// The send timestamp is embedded in the payload for latency measurement
+	if measureE2E && size < 8 {
+		size = 8
+	}
+
+	bp.published.Store(0)
+	bp.received.Store(0)
+	bp.failed.Store(0)
+	bp.ackLat = newLatencyHistogram()
+	bp.e2eLat = newLatencyHistogram()
+	bp.result = &benchResult{
+		Context:     bp.Data.CurrCtx.Name,
+		Subject:     subject,
+		Mode:        mode,
+		Started:     time.Now(),
+		Messages:    count,
+		MessageSize: size,
+		Publishers:  publishers,
+		TargetRate:  rate,
+	}
+	bp.stopCh = make(chan struct{})
+	logger.Info("Starting benchmark on %s: %d msgs x %d bytes, %d publishers, mode %s", subject, count, size, publishers, mode)
+	bp.notify("Benchmark running...", 3*time.Second, "info")
+
+	go bp.runBench(bp.result, measureE2E, bp.stopCh)
+}
+
+func (bp *BenchPage) stopBench() {
+	bp.runningMu.Lock()
+	defer bp.runningMu.Unlock()
+	if bp.stopCh != nil {
+		close(bp.stopCh)
+		bp.stopCh = nil
+	}
+}
+
+func (bp *BenchPage) runBench(res *benchResult, measureE2E bool, stopCh chan struct{}) {
+	// The subscriber side uses its own connection so it doesn't compete with
+	// the publishers for the socket
+	var subConn *nats.Conn
+	if measureE2E {
+		var err error
+		subConn, err = natsutil.Connect(&bp.Data.CurrCtx.CtxData)
+		if err != nil {
+			bp.notify("Failed to connect subscriber: "+err.Error(), 5*time.Second, "error")
+		} else {
+			defer subConn.Close()
+			_, err = subConn.Subscribe(res.Subject, func(msg *nats.Msg) {
+				if len(msg.Data) < 8 {
+					return
+				}
+				sentAt := int64(binary.BigEndian.Uint64(msg.Data[:8]))
+				lat := time.Duration(time.Now().UnixNano() - sentAt)
+				bp.received.Add(1)
+				bp.e2eLat.record(lat)
+			})
+			if err != nil {
+				bp.notify("Failed to subscribe: "+err.Error(), 5*time.Second, "error")
+			}
+			subConn.Flush()
+		}
+	}
+
+	started := time.Now()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				bp.showStats(res, time.Since(started), false)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for p := 0; p < res.Publishers; p++ {
+		// Spread the messages and the rate over the publishers
+		n := res.Messages / res.Publishers
+		if p < res.Messages%res.Publishers {
+			n++
+		}
+		pubRate := 0.0
+		if res.TargetRate > 0 {
+			pubRate = float64(res.TargetRate) / float64(res.Publishers)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bp.runPublisher(res, n, pubRate, stopCh)
+		}()
+	}
+	wg.Wait()
+
+	// Give the subscriber a moment to drain in-flight messages
+	if subConn != nil {
+		deadline := time.Now().Add(2 * time.Second)
+		for bp.received.Load() < bp.published.Load() && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	close(done)
+
+	elapsed := time.Since(started)
+	bp.finishResult(res, elapsed)
+	bp.showStats(res, elapsed, true)
+	logger.Info("Benchmark finished: %d published, %d errors in %s", res.Published, res.Errors, res.Duration)
+	if bp.Data.CurrCtx.LogFile != nil {
+		hourMinSec := time.Now().Format("15:04:05.00000")
+		bp.Data.CurrCtx.LogFile.WriteString(fmt.Sprintf("%s BENCH[%s] %d msgs in %s (%.0f msgs/sec)\n", hourMinSec, res.Subject, res.Published, res.Duration, res.MsgsPerSec))
+	}
+
+	bp.runningMu.Lock()
+	if bp.stopCh == stopCh {
+		close(bp.stopCh)
+		bp.stopCh = nil
+	}
+	bp.runningMu.Unlock()
+}
+
+func (bp *BenchPage) runPublisher(res *benchResult, count int, rate float64, stopCh chan struct{}) {
+	// Each publisher gets its own connection, like `nats bench`
+	conn, err := natsutil.Connect(&bp.Data.CurrCtx.CtxData)
+	if err != nil {
+		bp.failed.Add(int64(count))
+		bp.notify("Failed to connect publisher: "+err.Error(), 5*time.Second, "error")
+		return
+	}
+	defer conn.Close()
+
+	var js nats.JetStreamContext
+	if res.Mode == benchModeJetStream {
+		js, err = conn.JetStream(nats.PublishAsyncMaxPending(1024))
+		if err != nil {
+			bp.failed.Add(int64(count))
+			bp.notify("Failed to get JetStream context: "+err.Error(), 5*time.Second, "error")
+			return
+		}
+	}
+
+	var acks sync.WaitGroup
+	interval := time.Duration(0)
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	next := time.Now()
+
+	for i := 0; i < count; i++ {
+		select {
+		case <-stopCh:
+			acks.Wait()
+			return
+		default:
+		}
+		if interval > 0 {
+			next = next.Add(interval)
+			if wait := time.Until(next); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		payload := make([]byte, res.MessageSize)
+		sentAt := time.Now()
+		if len(payload) >= 8 {
+			binary.BigEndian.PutUint64(payload[:8], uint64(sentAt.UnixNano()))
+		}
+
+		if js == nil {
+			if err := conn.Publish(res.Subject, payload); err != nil {
+				bp.failed.Add(1)
+				continue
+			}
+			bp.published.Add(1)
+			continue
+		}
+
+		future, err := js.PublishAsync(res.Subject, payload)
+		if err != nil {
+			bp.failed.Add(1)
+			continue
+		}
+		bp.published.Add(1)
+		acks.Add(1)
+		go func() {
+			defer acks.Done()
+			select {
+			case <-future.Ok():
+				lat := time.Since(sentAt)
+				bp.ackLat.record(lat)
+			case <-future.Err():
+				bp.failed.Add(1)
+			}
+		}()
+	}
+
+	if js == nil {
+		conn.Flush()
+	}
+	acks.Wait()
+}
+
+func (bp *BenchPage) finishResult(res *benchResult, elapsed time.Duration) {
+	res.Duration = elapsed.Round(time.Millisecond).String()
+	res.Published = bp.published.Load()
+	res.Received = bp.received.Load()
+	res.Errors = bp.failed.Load()
+	if elapsed > 0 {
+		res.MsgsPerSec = float64(res.Published) / elapsed.Seconds()
+		res.BytesPerSec = res.MsgsPerSec * float64(res.MessageSize)
+	}
+	res.AckLatency = bp.ackLat.summary()
+	res.EndToEndLatency = bp.e2eLat.summary()
+}
+
+func (bp *BenchPage) showStats(res *benchResult, elapsed time.Duration, final bool) {
+	published := bp.published.Load()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(published) / elapsed.Seconds()
+	}
+
+	status := "RUNNING"
+	if final {
+		status = "DONE"
+	}
+	text := fmt.Sprintf("Status:     %s (%s)\nPublished:  %d / %d\nReceived:   %d\nErrors:     %d\nThroughput: %.0f msgs/sec  |  %s/sec\n",
+		status, elapsed.Round(time.Millisecond), published, res.Messages, bp.received.Load(), bp.failed.Load(),
+		rate, humanBytes(uint64(rate*float64(res.MessageSize))))
+
+	ack := bp.ackLat.summary()
+	e2e := bp.e2eLat.summary()
+	if ack != nil {
+		text += fmt.Sprintf("\nAck latency (%d):  min %s  p50 %s  p99 %s  p999 %s  max %s", ack.Count, ack.Min, ack.P50, ack.P99, ack.P999, ack.Max)
+	}
+	if e2e != nil {
+		text += fmt.Sprintf("\nEnd-to-end  (%d):  min %s  p50 %s  p99 %s  p999 %s  max %s", e2e.Count, e2e.Min, e2e.P50, e2e.P99, e2e.P999, e2e.Max)
+	}
+
+	bp.app.QueueUpdateDraw(func() {
+		bp.statsView.SetText(text)
+	})
+}
+
+func (bp *BenchPage) saveResults() {
+	if bp.result == nil || bp.result.Duration == "" {
+		bp.notify("No finished benchmark to save", 3*time.Second, "warn")
+		return
+	}
+	filePath := bp.form.GetFormItemByLabel("Results File").(*tview.InputField).GetText()
+	content, err := json.MarshalIndent(bp.result, "", "  ")
+	if err != nil {
+		bp.notify("Failed to encode results: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		bp.notify("Failed to save results: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	bp.notify("Results saved to "+filePath, 3*time.Second, "info")
+}
+
+func (bp *BenchPage) notify(message string, duration time.Duration, logLevel string) {
+	bp.footerTxt.SetText(message)
+	bp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		bp.footerTxt.SetText("")
+		bp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
+
+// Latencies are counted in log-linear buckets: every power of two is split
+// into 16 buckets, so percentiles are within about 3% of the real value and
+// recording never blocks the publishers.
+const (
+	latencySubBucketBits = 4
+	latencySubBuckets    = 1 << latencySubBucketBits
+	latencyBuckets       = (64 - latencySubBucketBits + 1) * latencySubBuckets
+)
+
+type latencyHistogram struct {
+	counts [latencyBuckets]atomic.Int64
+	min    atomic.Int64
+	max    atomic.Int64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	h := &latencyHistogram{}
+	h.min.Store(math.MaxInt64)
+	return h
+}
+
+func latencyBucket(v int64) int {
+	if v < latencySubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - 1 - latencySubBucketBits
+	return (shift+1)*latencySubBuckets + int(v>>shift) - latencySubBuckets
+}
+
+// latencyBucketValue is the middle of the values counted in the bucket
+func latencyBucketValue(idx int) int64 {
+	if idx < latencySubBuckets {
+		return int64(idx)
+	}
+	shift := idx/latencySubBuckets - 1
+	low := int64(latencySubBuckets+idx%latencySubBuckets) << shift
+	return low + (int64(1)<<shift)/2
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[latencyBucket(v)].Add(1)
+	for cur := h.min.Load(); v < cur && !h.min.CompareAndSwap(cur, v); cur = h.min.Load() {
+	}
+	for cur := h.max.Load(); v > cur && !h.max.CompareAndSwap(cur, v); cur = h.max.Load() {
+	}
+}
+
+// summary returns the percentiles of the recorded latencies, nil when there are none
+func (h *latencyHistogram) summary() *latencySummary {
+	if h == nil {
+		return nil
+	}
+	var counts [latencyBuckets]int64
+	total := int64(0)
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return nil
+	}
+	min, max := h.min.Load(), h.max.Load()
+
+	percentile := func(p float64) string {
+		rank := int64(float64(total-1) * p)
+		seen := int64(0)
+		for i, c := range counts {
+			seen += c
+			if seen > rank {
+				v := latencyBucketValue(i)
+				if v < min {
+					v = min
+				}
+				if v > max {
+					v = max
+				}
+				return time.Duration(v).String()
+			}
+		}
+		return time.Duration(max).String()
+	}
+	return &latencySummary{
+		Count: int(total),
+		Min:   time.Duration(min).String(),
+		P50:   percentile(0.50),
+		P99:   percentile(0.99),
+		P999:  percentile(0.999),
+		Max:   time.Duration(max).String(),
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// capturedMsg is a single message read back from a capture file
+type capturedMsg struct {
+	Subject string
+	Reply   string
+	Header  nats.Header
+	Data    []byte
+	Time    time.Time
+}
+
+// matches the message lines natsdash writes to the context log file, e.g.
+// "15:04:05.00000 SUB[orders.new] {...}" or "15:04:05.00000 [orders.new] {...}"
+var logMsgLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2}\.\d+) (SUB|PUB)?\[([^\]]+)\] ?(.*)$`)
+var logLineRe = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d+ `)
+
+// loadCapturedMessages reads an NDJSON capture (nats CLI or our own tools) or a
+// natsdash session log file and returns the messages in file order
+func loadCapturedMessages(path string) ([]capturedMsg, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return parseJSONCapture(trimmed)
+	}
+	return parseLogCapture(content)
+}
+
+func parseJSONCapture(content []byte) ([]capturedMsg, error) {
+	msgs := make([]capturedMsg, 0)
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("message %d: %v", len(msgs)+1, err)
+		}
+
+		// A top level array holds one message per element
+		docs := []interface{}{doc}
+		if arr, ok := doc.([]interface{}); ok {
+			docs = arr
+		}
+		for _, d := range docs {
+			record, ok := d.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("message %d: expected a JSON object", len(msgs)+1)
+			}
+			msg, err := capturedMsgFromRecord(record)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %v", len(msgs)+1, err)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// capturedMsgFromRecord converts a decoded JSON record into a message. Records
+// carrying a "seq" are raw stream messages whose data and headers are base64.
+func capturedMsgFromRecord(record map[string]interface{}) (capturedMsg, error) {
+	msg := capturedMsg{}
+	msg.Subject, _ = record["subject"].(string)
+	msg.Reply, _ = record["reply"].(string)
+
+	_, isStreamMsg := record["seq"]
+	base64Data := isStreamMsg || record["encoding"] == "base64"
+
+	switch data := record["data"].(type) {
+	case string:
+		if base64Data {
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return msg, fmt.Errorf("invalid base64 data: %v", err)
+			}
+			msg.Data = decoded
+		} else {
+			msg.Data = []byte(data)
+		}
+	case nil:
+	default:
+		// Structured payloads are published as their JSON encoding
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return msg, err
+		}
+		msg.Data = encoded
+	}
+	if data, ok := record["data_b64"].(string); ok {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return msg, fmt.Errorf("invalid base64 data: %v", err)
+		}
+		msg.Data = decoded
+	}
+
+	for _, key := range []string{"headers", "header"} {
+		if hdrs, ok := record[key].(map[string]interface{}); ok {
+			msg.Header = nats.Header{}
+			for k, v := range hdrs {
+				switch val := v.(type) {
+				case []interface{}:
+					for _, item := range val {
+						msg.Header.Add(k, fmt.Sprint(item))
+					}
+				default:
+					msg.Header.Add(k, fmt.Sprint(val))
+				}
+			}
+		}
+	}
+	if hdrs, ok := record["hdrs"].(string); ok && hdrs != "" {
+		raw, err := base64.StdEncoding.DecodeString(hdrs)
+		if err != nil {
+			return msg, fmt.Errorf("invalid base64 headers: %v", err)
+		}
+		msg.Header = parseRawHeaders(raw)
+	}
+
+	for _, key := range []string{"time", "timestamp", "received"} {
+		if ts, ok := record[key]; ok {
+			msg.Time = parseCaptureTime(ts)
+			break
+		}
+	}
+
+	if msg.Subject == "" {
+		return msg, fmt.Errorf("missing subject")
+	}
+	return msg, nil
+}
+
+// parseRawHeaders parses a "NATS/1.0" header block as stored by JetStream
+func parseRawHeaders(raw []byte) nats.Header {
+	hdr := nats.Header{}
+	lines := strings.Split(string(raw), "\r\n")
+	for i, line := range lines {
+		if i == 0 || line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			hdr.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		}
+	}
+	return hdr
+}
+
+func parseCaptureTime(v interface{}) time.Time {
+	switch ts := v.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return t
+		}
+	case json.Number:
+		// Accept unix seconds, milliseconds or nanoseconds
+		if n, err := strconv.ParseInt(ts.String(), 10, 64); err == nil {
+			switch {
+			case n > 1e17:
+				return time.Unix(0, n)
+			case n > 1e11:
+				return time.UnixMilli(n)
+			default:
+				return time.Unix(n, 0)
+			}
+		}
+		if f, err := ts.Float64(); err == nil {
+			return time.Unix(0, int64(f*float64(time.Second)))
+		}
+	}
+	return time.Time{}
+}
+
+// parseLogCapture reads received messages from a natsdash context log. Lines
+// without a timestamp are continuation lines of a multi-line payload.
+func parseLogCapture(content []byte) ([]capturedMsg, error) {
+	msgs := make([]capturedMsg, 0)
+	inMsg := false
+	// Log lines only carry the time of day, a time going back by more than
+	// half a day means the capture crossed midnight
+	var dayOffset time.Duration
+	var lastTs time.Time
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := logMsgLineRe.FindStringSubmatch(line); m != nil {
+			// Our own publishes are not part of the captured traffic
+			if m[2] == "PUB" {
+				inMsg = false
+				continue
+			}
+			ts, err := time.Parse("15:04:05.00000", m[1])
+			if err == nil {
+				ts = ts.Add(dayOffset)
+				if !lastTs.IsZero() && lastTs.Sub(ts) > 12*time.Hour {
+					dayOffset += 24 * time.Hour
+					ts = ts.Add(24 * time.Hour)
+				}
+				lastTs = ts
+			}
+			msgs = append(msgs, capturedMsg{Subject: m[3], Data: []byte(m[4]), Time: ts})
+			inMsg = true
+			continue
+		}
+		if logLineRe.MatchString(line) {
+			inMsg = false
+			continue
+		}
+		if inMsg {
+			last := &msgs[len(msgs)-1]
+			last.Data = append(append(last.Data, '\n'), line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no messages found")
+	}
+	return msgs, nil
+}
+
+// subjectRewriteRule maps subjects of captured messages onto new subjects
+type subjectRewriteRule struct {
+	kind     string // "prefix" or "token"
+	position int
+	from     string
+	to       string
+}
+
+// parseRewriteRules parses rules separated by ';', each one of
+//
+//	prefix <from> <to>        replace a leading subject prefix
+//	token <pos> <from|*> <to> replace the token at 1-based position pos
+func parseRewriteRules(text string) ([]subjectRewriteRule, error) {
+	rules := make([]subjectRewriteRule, 0)
+	for _, part := range strings.Split(text, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "prefix":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("prefix rule needs <from> <to>: %q", strings.TrimSpace(part))
+			}
+			rules = append(rules, subjectRewriteRule{kind: "prefix", from: fields[1], to: fields[2]})
+		case "token":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("token rule needs <pos> <from> <to>: %q", strings.TrimSpace(part))
+			}
+			pos, err := strconv.Atoi(fields[1])
+			if err != nil || pos < 1 {
+				return nil, fmt.Errorf("invalid token position: %q", fields[1])
+			}
+			rules = append(rules, subjectRewriteRule{kind: "token", position: pos, from: fields[2], to: fields[3]})
+		default:
+			return nil, fmt.Errorf("unknown rule type: %q", fields[0])
+		}
+	}
+	return rules, nil
+}
+
+func rewriteSubject(subject string, rules []subjectRewriteRule) string {
+	for _, rule := range rules {
+		switch rule.kind {
+		case "prefix":
+			if subject == rule.from {
+				subject = rule.to
+			} else if strings.HasPrefix(subject, rule.from+".") {
+				subject = rule.to + subject[len(rule.from):]
+			}
+		case "token":
+			tokens := strings.Split(subject, ".")
+			if rule.position <= len(tokens) && (rule.from == "*" || tokens[rule.position-1] == rule.from) {
+				tokens[rule.position-1] = rule.to
+				subject = strings.Join(tokens, ".")
+			}
+		}
+	}
+	return subject
+}
+
+// loadBatchMessages reads a batch file for publishing. With envelopes set,
+// JSON documents are message envelopes with "subject", "data" and "headers"
+// keys, otherwise every JSON document is published as-is. Non-JSON files
+// publish one message per line. Messages without a subject go to defaultSubject.
+func loadBatchMessages(path string, defaultSubject string, envelopes bool) ([]capturedMsg, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]capturedMsg, 0)
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("document %d: %v", len(msgs)+1, err)
+			}
+
+			docs := []json.RawMessage{raw}
+			var arr []json.RawMessage
+			if raw[0] == '[' && json.Unmarshal(raw, &arr) == nil {
+				docs = arr
+			}
+			for _, doc := range docs {
+				msg, err := batchMsgFromDocument(doc, defaultSubject, envelopes)
+				if err != nil {
+					return nil, fmt.Errorf("document %d: %v", len(msgs)+1, err)
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		return msgs, nil
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		msgs = append(msgs, capturedMsg{Subject: defaultSubject, Data: []byte(line)})
+	}
+	return msgs, nil
+}
+
+func batchMsgFromDocument(doc json.RawMessage, defaultSubject string, envelope bool) (capturedMsg, error) {
+	if envelope {
+		var record map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(doc))
+		dec.UseNumber()
+		if err := dec.Decode(&record); err != nil {
+			return capturedMsg{}, fmt.Errorf("expected a message envelope object")
+		}
+		if _, ok := record["subject"]; !ok {
+			record["subject"] = defaultSubject
+		}
+		return capturedMsgFromRecord(record)
+	}
+	if defaultSubject == "" {
+		return capturedMsg{}, fmt.Errorf("missing subject")
+	}
+	return capturedMsg{Subject: defaultSubject, Data: []byte(doc)}, nil
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+)
+
+// manifest section names shown as asset kinds
+var manifestSectionKinds = map[string]string{
+	"streams":       "stream",
+	"consumers":     "consumer",
+	"key_value":     "key_value",
+	"object_stores": "object_store",
+}
+
+// compareAsset is a stream, consumer or bucket found in either context
+type compareAsset struct {
+	section string
+	name    string
+	left    map[string]interface{} // nil when missing on the left
+	right   map[string]interface{} // nil when missing on the right
+	changes []configChange
+}
+
+// ComparePage shows the drift between the JetStream assets of two contexts
+// and pushes the configuration of one side to the other
+type ComparePage struct {
+	*tview.Flex
+	Data      *ds.Data
+	app       *tview.Application
+	form      *tview.Form
+	diffTable *tview.Table
+	tableBox  *tview.Flex
+	footerTxt *tview.TextView
+	leftName  string
+	rightName string
+	assets    []*compareAsset
+	rowAssets []int // asset index of every table row, -1 for the header
+	diffsOnly bool
+}
+
+func NewComparePage(app *tview.Application, data *ds.Data) *ComparePage {
+	cp := &ComparePage{
+		Flex:      tview.NewFlex().SetDirection(tview.FlexRow),
+		app:       app,
+		Data:      data,
+		diffsOnly: true,
+	}
+	cp.setupUI()
+	cp.setupInputCapture()
+	return cp
+}
+
+func (cp *ComparePage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back\n[Tab] Pick Contexts", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[r] Compare\n[d] Toggle Identical", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[>] Push Left to Right\n[<] Push Right to Left", tcell.ColorWhite), 0, 1, false)
+	cp.AddItem(headerRow, 4, 0, false)
+
+	cp.form = tview.NewForm().SetHorizontal(true)
+	cp.form.SetBorderPadding(0, 0, 1, 1)
+	cp.form.SetCancelFunc(func() {
+		cp.app.SetFocus(cp.diffTable)
+	})
+	cp.AddItem(cp.form, 3, 0, false)
+
+	cp.tableBox = tview.NewFlex()
+	cp.tableBox.SetBorder(true)
+	cp.tableBox.SetBorderPadding(0, 0, 1, 1)
+	cp.diffTable = tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	cp.tableBox.AddItem(cp.diffTable, 0, 1, true)
+	cp.AddItem(cp.tableBox, 0, 1, true)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	cp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(cp.footerTxt, 0, 1, false)
+	cp.AddItem(footer, 3, 0, false)
+
+	cp.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (cp *ComparePage) setupInputCapture() {
+	cp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if !cp.diffTable.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEsc:
+			cp.goBack()
+			return nil
+		case tcell.KeyTab:
+			cp.app.SetFocus(cp.form)
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'r', 'R':
+				cp.compare()
+				return nil
+			case 'd', 'D':
+				cp.diffsOnly = !cp.diffsOnly
+				cp.renderTable()
+				return nil
+			case '>':
+				cp.push(true)
+				return nil
+			case '<':
+				cp.push(false)
+				return nil
+			}
+		}
+		return event
+	})
+}
+
+// open compares the given context with another one picked on the page
+func (cp *ComparePage) open(leftName string) {
+	cp.leftName = leftName
+	if cp.rightName == leftName || cp.rightName == "" {
+		cp.rightName = ""
+		for _, ctx := range cp.Data.Contexts {
+			if ctx.Name != leftName {
+				cp.rightName = ctx.Name
+				break
+			}
+		}
+	}
+	cp.redraw(&cp.Data.CurrCtx)
+}
+
+func (cp *ComparePage) redraw(ctx *ds.Context) {
+	names := make([]string, 0, len(cp.Data.Contexts))
+	for _, c := range cp.Data.Contexts {
+		names = append(names, c.Name)
+	}
+	indexOf := func(name string) int {
+		for i, n := range names {
+			if n == name {
+				return i
+			}
+		}
+		return 0
+	}
+
+	cp.form.Clear(true)
+	cp.form.AddDropDown("Left", names, indexOf(cp.leftName), func(option string, _ int) {
+		cp.leftName = option
+	})
+	cp.form.AddDropDown("Right", names, indexOf(cp.rightName), func(option string, _ int) {
+		cp.rightName = option
+	})
+	cp.form.AddButton("Compare", func() {
+		cp.compare()
+		cp.app.SetFocus(cp.diffTable)
+	})
+
+	if len(names) < 2 {
+		cp.notify("Two contexts are needed for a comparison", 5*time.Second, "error")
+		return
+	}
+	cp.compare()
+	cp.app.SetFocus(cp.diffTable)
+}
+
+// compare exports both contexts and diffs their assets
+func (cp *ComparePage) compare() {
+	if cp.leftName == cp.rightName {
+		cp.notify("Pick two different contexts", 3*time.Second, "error")
+		return
+	}
+	leftName, rightName := cp.leftName, cp.rightName
+	cp.tableBox.SetTitle(fmt.Sprintf("Comparing %s and %s...", leftName, rightName))
+	go func() {
+		left, err := exportContext(leftName)
+		if err != nil {
+			cp.app.QueueUpdateDraw(func() { cp.notify(err.Error(), 5*time.Second, "error") })
+			return
+		}
+		right, err := exportContext(rightName)
+		if err != nil {
+			cp.app.QueueUpdateDraw(func() { cp.notify(err.Error(), 5*time.Second, "error") })
+			return
+		}
+		assets := compareManifests(left, right)
+		cp.app.QueueUpdateDraw(func() {
+			if leftName != cp.leftName || rightName != cp.rightName {
+				return // the selection changed meanwhile
+			}
+			cp.assets = assets
+			cp.renderTable()
+		})
+	}()
+}
+
+// exportContext reads the manifest of a saved context
+func exportContext(name string) (*jsManifest, error) {
+	conn, closeConn, err := contextConn(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", name, err)
+	}
+	defer closeConn()
+	m, err := exportManifest(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	return m, nil
+}
+
+// manifestEntryName identifies an entry within its section
+func manifestEntryName(section string, entry map[string]interface{}) string {
+	switch section {
+	case "streams":
+		name, _ := entry["name"].(string)
+		return name
+	case "consumers":
+		stream, _ := entry["stream"].(string)
+		name, _ := entry["durable_name"].(string)
+		if name == "" {
+			name, _ = entry["name"].(string)
+		}
+		return stream + "/" + name
+	default:
+		bucket, _ := entry["bucket"].(string)
+		return bucket
+	}
+}
+
+// compareManifests pairs the entries of both manifests by name
+func compareManifests(left, right *jsManifest) []*compareAsset {
+	assets := make([]*compareAsset, 0)
+	leftSections, rightSections := left.sections(), right.sections()
+	for _, section := range manifestSections {
+		byName := make(map[string]*compareAsset)
+		for _, entry := range *leftSections[section] {
+			name := manifestEntryName(section, entry)
+			byName[name] = &compareAsset{section: section, name: name, left: entry}
+		}
+		for _, entry := range *rightSections[section] {
+			name := manifestEntryName(section, entry)
+			if asset, ok := byName[name]; ok {
+				asset.right = entry
+			} else {
+				byName[name] = &compareAsset{section: section, name: name, right: entry}
+			}
+		}
+
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			asset := byName[name]
+			if asset.left != nil && asset.right != nil {
+				asset.changes = diffConfigs(asset.left, asset.right, nil)
+			}
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+func (cp *ComparePage) renderTable() {
+	selected, _ := cp.diffTable.GetSelection()
+	cp.diffTable.Clear()
+	cp.rowAssets = []int{-1}
+
+	headers := []string{"Asset", cp.leftName, cp.rightName}
+	for col, h := range headers {
+		cp.diffTable.SetCell(0, col, tview.NewTableCell(tview.Escape(h)).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+
+	missing, extra, drifted := 0, 0, 0
+	row := 1
+	addRow := func(index int, cells ...*tview.TableCell) {
+		for col, cell := range cells {
+			cp.diffTable.SetCell(row, col, cell.SetExpansion(1))
+		}
+		cp.rowAssets = append(cp.rowAssets, index)
+		row++
+	}
+	for i, asset := range cp.assets {
+		label := manifestSectionKinds[asset.section] + " " + asset.name
+		switch {
+		case asset.right == nil:
+			missing++
+			addRow(i, tview.NewTableCell(tview.Escape(label)).SetTextColor(tcell.ColorRed),
+				tview.NewTableCell("present"),
+				tview.NewTableCell("missing").SetTextColor(tcell.ColorRed))
+		case asset.left == nil:
+			extra++
+			addRow(i, tview.NewTableCell(tview.Escape(label)).SetTextColor(tcell.ColorRed),
+				tview.NewTableCell("missing").SetTextColor(tcell.ColorRed),
+				tview.NewTableCell("present"))
+		case len(asset.changes) > 0:
+			drifted++
+			addRow(i, tview.NewTableCell(tview.Escape(label)).SetTextColor(tcell.ColorYellow),
+				tview.NewTableCell(fmt.Sprintf("%d field(s) differ", len(asset.changes))).SetTextColor(tcell.ColorYellow),
+				tview.NewTableCell(""))
+			for _, c := range asset.changes {
+				addRow(i, tview.NewTableCell("    "+tview.Escape(c.Path)),
+					tview.NewTableCell(tview.Escape(formatDiffValue(c.Path, c.Old))),
+					tview.NewTableCell(tview.Escape(formatDiffValue(c.Path, c.New))))
+			}
+		case !cp.diffsOnly:
+			addRow(i, tview.NewTableCell(tview.Escape(label)).SetTextColor(tcell.ColorGreen),
+				tview.NewTableCell("identical").SetTextColor(tcell.ColorGreen),
+				tview.NewTableCell("identical").SetTextColor(tcell.ColorGreen))
+		}
+	}
+
+	title := fmt.Sprintf("%s vs %s: %d only left, %d only right, %d differ, %d identical",
+		cp.leftName, cp.rightName, missing, extra, drifted, len(cp.assets)-missing-extra-drifted)
+	cp.tableBox.SetTitle(tview.Escape(title))
+	if row == 1 {
+		cp.diffTable.SetCell(1, 0, tview.NewTableCell("No differences").SetSelectable(false))
+		return
+	}
+	if selected < 1 || selected >= row {
+		selected = 1
+	}
+	cp.diffTable.Select(selected, 0)
+}
+
+// push applies the configuration of the selected asset from one side to the
+// other, using the same plan as `natsdash apply`
+func (cp *ComparePage) push(leftToRight bool) {
+	row, _ := cp.diffTable.GetSelection()
+	if row < 1 || row >= len(cp.rowAssets) {
+		cp.notify("No asset selected", 3*time.Second, "error")
+		return
+	}
+	asset := cp.assets[cp.rowAssets[row]]
+	source, from, to := asset.left, cp.leftName, cp.rightName
+	if !leftToRight {
+		source, from, to = asset.right, cp.rightName, cp.leftName
+	}
+	if source == nil {
+		cp.notify(fmt.Sprintf("%s doesn't exist in %s, nothing to push", asset.name, from), 3*time.Second, "error")
+		return
+	}
+
+	// The pushed config replaces the target's, so keys only set there are reset
+	m := &jsManifest{replace: true}
+	*m.sections()[asset.section] = []map[string]interface{}{source}
+	go func() {
+		conn, closeConn, err := contextConn(to)
+		if err != nil {
+			cp.app.QueueUpdateDraw(func() { cp.notify("Failed to connect to "+to+": "+err.Error(), 5*time.Second, "error") })
+			return
+		}
+		plan, err := planManifest(conn, m)
+		closeConn()
+		cp.app.QueueUpdateDraw(func() {
+			if err != nil {
+				cp.notify("Failed to plan the push: "+err.Error(), 5*time.Second, "error")
+				return
+			}
+			cp.confirmPush(plan, m, asset, from, to)
+		})
+	}()
+}
+
+func (cp *ComparePage) confirmPush(plan *applyPlan, m *jsManifest, asset *compareAsset, from, to string) {
+	if _, blocked := plan.counts(); blocked > 0 {
+		cp.notify(plan.actions[0].err.Error(), 5*time.Second, "error")
+		return
+	}
+	if !plan.pending() {
+		cp.notify("Nothing to push", 3*time.Second, "info")
+		return
+	}
+	title := fmt.Sprintf("Push %s from %s to %s", asset.name, from, to)
+	showConfirmModal(title, plan.format(true), func() {
+		go func() {
+			err := pushManifest(to, m)
+			cp.app.QueueUpdateDraw(func() {
+				if err != nil {
+					cp.notify("Push failed: "+err.Error(), 5*time.Second, "error")
+					return
+				}
+				logger.Info("Pushed %s %s from %s to %s", asset.section, asset.name, from, to)
+				cp.notify(fmt.Sprintf("Pushed %s to %s", asset.name, to), 3*time.Second, "info")
+				cp.compare()
+			})
+		}()
+	})
+}
+
+// pushManifest plans the manifest again on a fresh connection, the server may
+// have changed since the confirmation, and applies it
+func pushManifest(ctxName string, m *jsManifest) error {
+	conn, closeConn, err := contextConn(ctxName)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	plan, err := planManifest(conn, m)
+	if err != nil {
+		return err
+	}
+	return plan.execute(nil)
+}
+
+func (cp *ComparePage) goBack() {
+	pages.SwitchToPage("contexts")
+	_, b := pages.GetFrontPage()
+	b.(*ContextPage).Redraw()
+	cp.app.SetFocus(b)
+}
+
+func (cp *ComparePage) notify(message string, duration time.Duration, logLevel string) {
+	cp.footerTxt.SetText(message)
+	cp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		cp.footerTxt.SetText("")
+		cp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
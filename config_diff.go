@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// configChange is a single field that differs between two configurations
+type configChange struct {
+	Path      string
+	Old       interface{}
+	New       interface{}
+	Immutable string // why the server won't accept the change, empty if it will
+}
+
+// Fields the server refuses to change on an existing stream or consumer
+var streamImmutableFields = map[string]string{
+	"name":      "streams can't be renamed",
+	"storage":   "the storage type can't be changed",
+	"retention": "the retention policy can't be changed",
+	"mirror":    "the mirror configuration can't be changed",
+}
+
+var consumerImmutableFields = map[string]string{
+	"name":           "consumers can't be renamed",
+	"durable_name":   "consumers can't be renamed",
+	"deliver_policy": "the deliver policy can't be changed",
+	"opt_start_seq":  "the start sequence can't be changed",
+	"opt_start_time": "the start time can't be changed",
+	"ack_policy":     "the ack policy can't be changed",
+	"replay_policy":  "the replay policy can't be changed",
+	"mem_storage":    "the storage type can't be changed",
+	"max_waiting":    "max_waiting can't be changed",
+}
+
+// Wire fields holding nanoseconds, shown as durations in the diff
+var durationConfigKeys = map[string]bool{
+	"max_age":            true,
+	"duplicate_window":   true,
+	"ack_wait":           true,
+	"idle_heartbeat":     true,
+	"inactive_threshold": true,
+	"max_expires":        true,
+	"ttl":                true,
+}
+
+// diffConfigs compares two wire configurations field by field. Nested objects
+// are compared per key, lists as a whole. Absent keys equal empty values
+// because the server omits those.
+func diffConfigs(oldConfig, newConfig map[string]interface{}, immutable map[string]string) []configChange {
+	changes := make([]configChange, 0)
+	diffConfigMaps("", normalizeConfigValue(oldConfig), normalizeConfigValue(newConfig), immutable, &changes)
+	return changes
+}
+
+func diffConfigMaps(prefix string, oldValue, newValue interface{}, immutable map[string]string, changes *[]configChange) {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if !(oldIsMap || oldValue == nil) || !(newIsMap || newValue == nil) || (oldValue == nil && newValue == nil) {
+		return
+	}
+
+	keys := make([]string, 0, len(oldMap)+len(newMap))
+	for k := range oldMap {
+		keys = append(keys, k)
+	}
+	for k := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		o, n := oldMap[k], newMap[k]
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+		_, oIsMap := o.(map[string]interface{})
+		_, nIsMap := n.(map[string]interface{})
+		if (oIsMap || o == nil) && (nIsMap || n == nil) && (oIsMap || nIsMap) && immutable[path] == "" {
+			diffConfigMaps(path, o, n, immutable, changes)
+			continue
+		}
+		*changes = append(*changes, configChange{Path: path, Old: o, New: n, Immutable: immutable[path]})
+	}
+}
+
+// normalizeConfigValue decodes v as plain JSON and drops empty values so that
+// omitted and zero fields compare equal
+func normalizeConfigValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return v
+	}
+	return dropEmptyValues(decoded)
+}
+
+func dropEmptyValues(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			child = dropEmptyValues(child)
+			if child == nil {
+				delete(t, k)
+			} else {
+				t[k] = child
+			}
+		}
+		if len(t) == 0 {
+			return nil
+		}
+		return t
+	case []interface{}:
+		if len(t) == 0 {
+			return nil
+		}
+		return t
+	case bool:
+		if !t {
+			return nil
+		}
+	case float64:
+		if t == 0 {
+			return nil
+		}
+	case string:
+		if t == "" {
+			return nil
+		}
+	}
+	return v
+}
+
+func formatDiffValue(path string, v interface{}) string {
+	if v == nil {
+		return "(unset)"
+	}
+	key := path[strings.LastIndex(path, ".")+1:]
+	if n, ok := v.(float64); ok && durationConfigKeys[key] {
+		return formatHumanDuration(time.Duration(int64(n)))
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// showConfigDiffModal lists the changes and calls onConfirm when the user
+// accepts them. Esc goes back to the editor.
+func showConfigDiffModal(title string, changes []configChange, onConfirm func()) {
+	var sb strings.Builder
+	blocked := 0
+	for _, c := range changes {
+		if c.Immutable != "" {
+			blocked++
+		}
+	}
+	if blocked > 0 {
+		sb.WriteString(fmt.Sprintf("[red::b]%d change(s) will be rejected by the server[-::-]\n\n", blocked))
+	}
+	for _, c := range changes {
+		color := "yellow"
+		if c.Immutable != "" {
+			color = "red"
+		}
+		sb.WriteString(fmt.Sprintf("[%s::b]%s[-::-]\n", color, tview.Escape(c.Path)))
+		sb.WriteString("  [red]- " + tview.Escape(formatDiffValue(c.Path, c.Old)) + "[-]\n")
+		sb.WriteString("  [green]+ " + tview.Escape(formatDiffValue(c.Path, c.New)) + "[-]\n")
+		if c.Immutable != "" {
+			sb.WriteString("  [red]! " + tview.Escape(c.Immutable) + "[-]\n")
+		}
+	}
+
+	showConfirmModal(fmt.Sprintf("%s (%d changes)", title, len(changes)), sb.String(), onConfirm)
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/yosuke-furukawa/json5/encoding/json5"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	formatJSON5 = "JSON5"
+	formatYAML  = "YAML"
+	formatJSON  = "JSON"
+)
+
+// Ctrl+T cycles through the formats in this order
+var configFormats = []string{formatJSON5, formatYAML, formatJSON}
+
+var configFormatExtensions = map[string]string{
+	formatJSON5: ".json5",
+	formatYAML:  ".yaml",
+	formatJSON:  ".json",
+}
+
+// configSchema describes a configuration for validation and completion
+type configSchema struct {
+	fields    []configField
+	enums     map[string][]string
+	durations []string // top level keys written like "30s"
+	subjects  []string // keys holding a subject or a list of subjects
+	strict    bool     // unknown keys are errors rather than warnings
+	convert   func(values map[string]interface{}) error
+}
+
+var streamConfigSchema = &configSchema{
+	fields: streamConfigFields,
+	enums: map[string][]string{
+		"retention":   {"limits", "interest", "workqueue"},
+		"storage":     {"file", "memory"},
+		"discard":     {"old", "new"},
+		"compression": {"none", "s2"},
+	},
+	durations: streamDurationFields,
+	subjects:  []string{"subjects"},
+	convert: func(values map[string]interface{}) error {
+		_, _, err := streamConfigFromValues(values)
+		return err
+	},
+}
+
+var consumerConfigSchema = &configSchema{
+	fields: consumerConfigFields,
+	enums: map[string][]string{
+		"deliver_policy": {"all", "last", "new", "by_start_sequence", "by_start_time", "last_per_subject"},
+		"ack_policy":     {"none", "all", "explicit"},
+		"replay_policy":  {"instant", "original"},
+	},
+	durations: consumerDurationFields,
+	subjects:  []string{"filter_subject", "filter_subjects", "deliver_subject"},
+	strict:    true,
+	convert: func(values map[string]interface{}) error {
+		_, _, err := consumerConfigFromValues(values)
+		return err
+	},
+}
+
+// configIssue is a validation finding. Warnings don't prevent saving.
+type configIssue struct {
+	line    int // 1-based, 0 when unknown
+	message string
+	warning bool
+}
+
+// configEditor is a text area for stream and consumer configurations that
+// validates while typing, completes keys and enum values, switches between
+// JSON5, YAML and JSON and can hand the content to an external editor
+type configEditor struct {
+	textArea   *tview.TextArea
+	statusView *tview.TextView
+	schema     *configSchema
+	format     string
+	title      string
+	issues     []configIssue
+}
+
+func newConfigEditor(schema *configSchema, format, title string) *configEditor {
+	e := &configEditor{
+		textArea:   tview.NewTextArea(),
+		statusView: tview.NewTextView().SetDynamicColors(true).SetScrollable(true),
+		schema:     schema,
+		format:     format,
+		title:      title,
+	}
+	e.textArea.SetBorder(true)
+	e.statusView.SetBorder(true)
+	e.statusView.SetTitle("Validation")
+	e.statusView.SetBorderPadding(0, 0, 1, 1)
+	e.textArea.SetChangedFunc(e.validate)
+	e.updateTitle()
+	return e
+}
+
+func (e *configEditor) setTitle(title string) {
+	e.title = title
+	e.updateTitle()
+}
+
+func (e *configEditor) updateTitle() {
+	e.textArea.SetTitle(fmt.Sprintf("%s (%s)", e.title, e.format))
+}
+
+// setText replaces the content, which must be in the current format
+func (e *configEditor) setText(text string) {
+	e.textArea.SetText(text, false)
+	e.validate()
+}
+
+// setValues renders the values in the current format
+func (e *configEditor) setValues(values map[string]interface{}) {
+	e.setText(formatConfig(e.format, e.schema.fields, values))
+}
+
+// values parses the content in the current format
+func (e *configEditor) values() (map[string]interface{}, error) {
+	values, err := parseConfigText(e.format, e.textArea.GetText())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s configuration: %v", e.format, err)
+	}
+	return values, nil
+}
+
+// handleKey processes the editor shortcuts and returns nil for handled keys
+func (e *configEditor) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyCtrlT:
+		e.toggleFormat()
+		return nil
+	case tcell.KeyCtrlSpace:
+		e.showCompletion()
+		return nil
+	case tcell.KeyCtrlE:
+		e.gotoIssue()
+		return nil
+	case tcell.KeyCtrlG:
+		e.editExternally()
+		return nil
+	}
+	return event
+}
+
+// editExternally opens the content in the external editor and validates
+// what comes back. The content is kept when the editor fails.
+func (e *configEditor) editExternally() {
+	text, err := editExternally(e.textArea.GetText(), configFormatExtensions[e.format])
+	if err != nil {
+		e.statusView.SetText("[red]" + tview.Escape(err.Error()) + "[-]")
+		return
+	}
+	e.setText(text)
+}
+
+// toggleFormat converts the content to the next format. Content that doesn't
+// parse stays as it is.
+func (e *configEditor) toggleFormat() {
+	values, err := e.values()
+	if err != nil {
+		e.statusView.SetText("[red]" + tview.Escape(err.Error()) + "[-]\nFix the configuration before switching the format")
+		return
+	}
+	for i, f := range configFormats {
+		if f == e.format {
+			e.format = configFormats[(i+1)%len(configFormats)]
+			break
+		}
+	}
+	e.updateTitle()
+	e.setValues(values)
+}
+
+func (e *configEditor) validate() {
+	e.issues = validateConfigText(e.schema, e.format, e.textArea.GetText())
+	if len(e.issues) == 0 {
+		e.statusView.SetText("[green]Valid " + e.format + "[-]")
+		return
+	}
+	var sb strings.Builder
+	for _, issue := range e.issues {
+		color := "red"
+		if issue.warning {
+			color = "yellow"
+		}
+		location := ""
+		if issue.line > 0 {
+			location = fmt.Sprintf("line %d: ", issue.line)
+		}
+		sb.WriteString(fmt.Sprintf("[%s]%s%s[-]\n", color, location, tview.Escape(issue.message)))
+	}
+	e.statusView.SetText(strings.TrimSpace(sb.String()))
+	e.statusView.ScrollToBeginning()
+}
+
+// gotoIssue selects the line of the first issue
+func (e *configEditor) gotoIssue() {
+	for _, issue := range e.issues {
+		if issue.line == 0 {
+			continue
+		}
+		start, end := lineOffsets(e.textArea.GetText(), issue.line)
+		e.textArea.Select(start, end)
+		return
+	}
+}
+
+var (
+	keyPrefixRe   = regexp.MustCompile(`^\s*(-\s*)?("?)([A-Za-z_]*)$`)
+	valuePrefixRe = regexp.MustCompile(`^\s*"?([A-Za-z_]+)"?\s*:\s*("?)([A-Za-z0-9_]*)$`)
+)
+
+// showCompletion offers the keys or the values of the key at the cursor
+func (e *configEditor) showCompletion() {
+	text, cursor, _ := e.textArea.GetSelection()
+	if text != "" {
+		return
+	}
+	full := e.textArea.GetText()
+	lineStart := strings.LastIndex(full[:cursor], "\n") + 1
+	line := full[lineStart:cursor]
+
+	var options []string
+	var prefix, quote string
+	isKey := false
+	if m := valuePrefixRe.FindStringSubmatch(line); m != nil {
+		quote, prefix = m[2], m[3]
+		options = e.valueOptions(m[1])
+	} else if m := keyPrefixRe.FindStringSubmatch(line); m != nil {
+		quote, prefix = m[2], m[3]
+		isKey = true
+		for _, f := range e.schema.fields {
+			options = append(options, f.key)
+		}
+	}
+
+	items := make([]pickerItem, 0)
+	matches := make([]string, 0)
+	for _, option := range options {
+		if strings.HasPrefix(option, prefix) {
+			matches = append(matches, option)
+			items = append(items, pickerItem{Text: option, Secondary: e.optionHint(option, isKey)})
+		}
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	showPickerModal("Complete", items, func(idx int) {
+		insert := matches[idx]
+		switch {
+		case isKey && e.format == formatJSON && quote == "":
+			insert = `"` + insert + `": `
+		case isKey && quote != "":
+			insert += `": `
+		case isKey:
+			insert += ": "
+		case insert == "true" || insert == "false":
+		case quote != "":
+			insert += `"`
+		case e.format != formatYAML:
+			insert = `"` + insert + `"`
+		}
+		e.textArea.Replace(cursor-len(prefix), cursor, insert)
+	})
+}
+
+// valueOptions are the completions for the value of key
+func (e *configEditor) valueOptions(key string) []string {
+	if values, ok := e.schema.enums[key]; ok {
+		return values
+	}
+	for _, f := range e.schema.fields {
+		if _, isBool := f.value.(bool); isBool && f.key == key {
+			return []string{"true", "false"}
+		}
+	}
+	return nil
+}
+
+func (e *configEditor) optionHint(option string, isKey bool) string {
+	if !isKey {
+		return ""
+	}
+	for _, f := range e.schema.fields {
+		if f.key == option && len(f.comment) > 0 {
+			return f.comment[0]
+		}
+	}
+	return ""
+}
+
+// formatConfig renders values in the given format. JSON5 and YAML include
+// the field comments.
+func formatConfig(format string, fields []configField, values map[string]interface{}) string {
+	switch format {
+	case formatYAML:
+		return formatConfigYAML(fields, values)
+	case formatJSON:
+		return formatConfigJSON(fields, values)
+	default:
+		return formatConfigJSON5(fields, values)
+	}
+}
+
+// orderedConfigKeys returns the keys of values, documented fields first
+func orderedConfigKeys(fields []configField, values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	known := make(map[string]bool)
+	for _, f := range fields {
+		known[f.key] = true
+		if _, ok := values[f.key]; ok {
+			keys = append(keys, f.key)
+		}
+	}
+	extra := make([]string, 0)
+	for k := range values {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+func formatConfigYAML(fields []configField, values map[string]interface{}) string {
+	comments := make(map[string][]string)
+	for _, f := range fields {
+		comments[f.key] = f.comment
+	}
+	entries := make([]string, 0, len(values))
+	for _, k := range orderedConfigKeys(fields, values) {
+		var sb strings.Builder
+		for _, c := range comments[k] {
+			sb.WriteString("# " + c + "\n")
+		}
+		b, err := yaml.Marshal(map[string]interface{}{k: values[k]})
+		if err != nil {
+			continue
+		}
+		sb.WriteString(strings.TrimRight(string(b), "\n"))
+		entries = append(entries, sb.String())
+	}
+	return strings.Join(entries, "\n\n") + "\n"
+}
+
+func formatConfigJSON(fields []configField, values map[string]interface{}) string {
+	entries := make([]string, 0, len(values))
+	for _, k := range orderedConfigKeys(fields, values) {
+		b, err := json.MarshalIndent(values[k], "  ", "  ")
+		if err != nil {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("  %q: %s", k, b))
+	}
+	return "{\n" + strings.Join(entries, ",\n") + "\n}"
+}
+
+// parseConfigText parses text in the given format
+func parseConfigText(format, text string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	switch format {
+	case formatYAML:
+		var raw map[interface{}]interface{}
+		if err := yaml.Unmarshal([]byte(text), &raw); err != nil {
+			return nil, err
+		}
+		for k := range raw {
+			if _, ok := k.(string); !ok {
+				return nil, fmt.Errorf("key %v isn't a string", k)
+			}
+		}
+		values = convertToStringMap(raw)
+	case formatJSON:
+		if err := json.Unmarshal([]byte(text), &values); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json5.Unmarshal([]byte(text), &values); err != nil {
+			return nil, err
+		}
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return values, nil
+}
+
+var (
+	yamlErrorLineRe = regexp.MustCompile(`line (\d+)`)
+	structFieldRe   = regexp.MustCompile(`Go struct field \w+\.([\w.]+)`)
+	leadingKeyRe    = regexp.MustCompile(`^(\w+)[: ]`)
+)
+
+// validateConfigText checks the syntax, the keys, enums, durations and
+// subjects and finally runs the same conversion as saving does
+func validateConfigText(schema *configSchema, format, text string) []configIssue {
+	values, err := parseConfigText(format, text)
+	if err != nil {
+		return []configIssue{{line: syntaxErrorLine(err, text), message: err.Error()}}
+	}
+
+	issues := make([]configIssue, 0)
+	errorCount := 0
+	addError := func(line int, message string) {
+		issues = append(issues, configIssue{line: line, message: message})
+		errorCount++
+	}
+	known := make(map[string]bool)
+	for _, f := range schema.fields {
+		known[f.key] = true
+	}
+	for _, k := range orderedConfigKeys(nil, values) {
+		if !known[k] && schema.strict {
+			addError(keyLine(text, k), "unknown key "+k)
+		} else if !known[k] {
+			issues = append(issues, configIssue{line: keyLine(text, k), message: "unknown key " + k, warning: true})
+		}
+	}
+
+	for key, allowed := range schema.enums {
+		v, ok := values[key]
+		if !ok || v == nil {
+			continue
+		}
+		s, isString := v.(string)
+		if !isString || !containsString(allowed, s) {
+			addError(keyLine(text, key), fmt.Sprintf("%s must be one of %s", key, strings.Join(allowed, ", ")))
+		}
+	}
+	for _, key := range schema.durations {
+		if s, ok := values[key].(string); ok {
+			if _, err := parseHumanDuration(s); err != nil {
+				addError(keyLine(text, key), fmt.Sprintf("%s: invalid duration %q", key, s))
+			}
+		}
+	}
+	for _, key := range schema.subjects {
+		var subjects []interface{}
+		switch v := values[key].(type) {
+		case string:
+			if v != "" {
+				subjects = []interface{}{v}
+			}
+		case []interface{}:
+			subjects = v
+		}
+		for _, subject := range subjects {
+			s, _ := subject.(string)
+			if !isValidSubject(s) {
+				line := keyLine(text, key)
+				if idx := strings.Index(text, s); s != "" && idx >= 0 {
+					line = strings.Count(text[:idx], "\n") + 1
+				}
+				addError(line, fmt.Sprintf("%s: invalid subject %q", key, s))
+			}
+		}
+	}
+
+	if errorCount == 0 && schema.convert != nil {
+		if err := schema.convert(values); err != nil {
+			line := 0
+			if m := structFieldRe.FindStringSubmatch(err.Error()); m != nil {
+				parts := strings.Split(m[1], ".")
+				line = keyLine(text, parts[len(parts)-1])
+			} else if m := leadingKeyRe.FindStringSubmatch(err.Error()); m != nil {
+				line = keyLine(text, m[1])
+			}
+			addError(line, err.Error())
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].line < issues[j].line })
+	return issues
+}
+
+// syntaxErrorLine finds the line of a parse error
+func syntaxErrorLine(err error, text string) int {
+	offset := int64(-1)
+	var jsonErr *json.SyntaxError
+	var json5Err *json5.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &jsonErr):
+		offset = jsonErr.Offset
+	case errors.As(err, &json5Err):
+		offset = json5Err.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		if m := yamlErrorLineRe.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return line
+		}
+		return 0
+	}
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	return strings.Count(text[:offset], "\n") + 1
+}
+
+// keyLine returns the line where key is defined, 0 if it isn't found
+func keyLine(text, key string) int {
+	re, err := regexp.Compile(`(?m)^[ \t-]*"?` + regexp.QuoteMeta(key) + `"?[ \t]*:`)
+	if err != nil {
+		return 0
+	}
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return 0
+	}
+	return strings.Count(text[:loc[0]], "\n") + 1
+}
+
+// lineOffsets returns the byte range of the 1-based line
+func lineOffsets(text string, line int) (int, int) {
+	start := 0
+	for i := 1; i < line; i++ {
+		next := strings.IndexByte(text[start:], '\n')
+		if next < 0 {
+			return len(text), len(text)
+		}
+		start += next + 1
+	}
+	end := strings.IndexByte(text[start:], '\n')
+	if end < 0 {
+		return start, len(text)
+	}
+	return start, start + end
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
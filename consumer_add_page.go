@@ -2,7 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -10,13 +13,13 @@ import (
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
 	"github.com/solidpulse/natsdash/logger"
-	"gopkg.in/yaml.v2"
 )
 
 type ConsumerAddPage struct {
 	*tview.Flex
 	Data         *ds.Data
 	app          *tview.Application
+	editor       *configEditor
 	txtArea      *tview.TextArea
 	footerTxt    *tview.TextView
 	streamName   string
@@ -33,13 +36,13 @@ func NewConsumerAddPage(app *tview.Application, data *ds.Data) *ConsumerAddPage
 
 	// Create header
 	headerRow := tview.NewFlex().SetDirection(tview.FlexColumn)
-	headerTxtView := createTextView("[ESC] Back    [Alt+Enter] Save", tcell.ColorWhite)
+	headerTxtView := createTextView("[ESC] Back    [Alt+Enter] Save    [Ctrl+T] YAML/JSON/JSON5    [Ctrl+Space] Complete    [Ctrl+E] Go to Error    [Ctrl+G] External Editor", tcell.ColorWhite)
 	headerTxtView.SetBorderPadding(1,1,1,1)
 	headerRow.AddItem(headerTxtView, 0, 1, false)
 
-	// Create text area
-	cap.txtArea = tview.NewTextArea()
-	cap.txtArea.SetBorder(true)
+	// Create the editor, validated while typing
+	cap.editor = newConfigEditor(consumerConfigSchema, formatYAML, "Add Consumer")
+	cap.txtArea = cap.editor.textArea
 	
 	// Create footer
 	cap.footerTxt = createTextView("", tcell.ColorWhite)
@@ -48,6 +51,7 @@ func NewConsumerAddPage(app *tview.Application, data *ds.Data) *ConsumerAddPage
 	// Add all components
 	cap.AddItem(headerRow, 3, 0, false).
 		AddItem(cap.txtArea, 0, 1, true).
+		AddItem(cap.editor.statusView, 6, 0, false).
 		AddItem(cap.footerTxt, 3, 0, false)
 
 	cap.setupInputCapture()
@@ -61,7 +65,7 @@ func (cap *ConsumerAddPage) redraw(ctx *ds.Context) {
 	if cap.isEdit {
 		title = "Edit Consumer: " + cap.consumerName
 	}
-	cap.txtArea.SetTitle(title)
+	cap.editor.setTitle(title)
 
 	if cap.isEdit {
 		// Get existing consumer config
@@ -90,77 +94,22 @@ func (cap *ConsumerAddPage) redraw(ctx *ds.Context) {
 		}
 
 		// Convert duration fields to strings
-		if ackWait, ok := configMap["ack_wait"].(float64); ok {
-			configMap["ack_wait"] = time.Duration(ackWait).String()
-		}
-		if idleHeartbeat, ok := configMap["idle_heartbeat"].(float64); ok {
-			configMap["idle_heartbeat"] = time.Duration(idleHeartbeat).String()
-		}
+		consumerEditorValues(configMap)
 
-		// Convert to YAML
-		yamlBytes, err := yaml.Marshal(configMap)
+		// The pause isn't part of the nats.go config
+		pauseUntil, err := livePauseUntil(ctx.Conn, cap.streamName, cap.consumerName)
 		if err != nil {
-			cap.notify("Failed to convert config to YAML: "+err.Error(), 3*time.Second, "error")
+			cap.notify("Failed to get consumer info: "+err.Error(), 3*time.Second, "error")
 			return
 		}
+		if pauseUntil != nil {
+			configMap["pause_until"] = pauseUntil
+		}
 
-		cap.txtArea.SetText(string(yamlBytes), false)
+		cap.editor.setValues(configMap)
 	} else {
-		// Set default template for new consumer
-		defaultConfig := `# Name of the consumer (required)
-# Will be set automatically from previous screen
-name: ""
-
-# Durable name for the consumer (optional)
-# Makes this a durable consumer that survives restarts
-durable_name: "NEW"
-
-# Pull mode configuration (optional)
-# true: pull-based / false: push-based
-pull: true
-
-# Subject filter for the consumer (required)
-# Examples: "ORDERS.*", "ORDERS.>", "ORDERS.*.received"
-filter_subject: "ORDERS.received"
-
-# Delivery policy configuration (optional)
-# Only one of these should be true
-deliver_all: true        # Deliver all available messages
-deliver_last: false      # Deliver only the last message
-# Other options (set all false if using deliver_all):
-# deliver_new: false   # Only new messages
-# deliver_by_start_sequence: false  # Start from specific sequence
-# deliver_by_start_time: false      # Start from specific time
-
-# Acknowledgment policy (required)
-# Options: "none", "all", "explicit"
-ack_policy: "explicit"
-
-# Acknowledgment wait time (optional)
-# How long to wait for ack before redelivery
-# Format: "30s", "1m", "1h"
-ack_wait: "30s"
-
-# Replay policy (required)
-# Options: "instant", "original"
-replay_policy: "instant"
-
-# Maximum delivery attempts (optional)
-# How many times to attempt delivery before giving up
-max_deliver: 20
-
-# Sampling rate percentage (optional)
-# 1-100, where 100 means all messages
-sample_freq: 100
-
-# Other available options:
-# max_ack_pending: 1000    # Maximum pending acks
-# max_waiting: 512         # Maximum waiting pulls
-# max_batch: 100          # Maximum batch size for pull
-# idle_heartbeat: "30s"   # Idle heartbeat interval
-# flow_control: false     # Enable flow control
-# headers_only: false     # Deliver only headers`
-		cap.txtArea.SetText(defaultConfig, false)
+		// Start from a durable pull consumer
+		cap.editor.setValues(newConsumerConfigValues())
 	}
 }
 	
@@ -193,7 +142,7 @@ func (cap *ConsumerAddPage) setupInputCapture() {
 			cap.saveConsumer()
 			return nil
 		}
-		return event
+		return cap.editor.handleKey(event)
 	})
 }
 func (cap *ConsumerAddPage) saveConsumer() {
@@ -204,60 +153,66 @@ func (cap *ConsumerAddPage) saveConsumer() {
 		return
 	}
 
-	// First convert YAML to map
-	var yamlData map[interface{}]interface{}
-	if err := yaml.Unmarshal([]byte(cap.txtArea.GetText()), &yamlData); err != nil {
-		cap.notify("Invalid YAML configuration: "+err.Error(), 3*time.Second, "error")
+	values, err := cap.editor.values()
+	if err != nil {
+		cap.notify(err.Error(), 3*time.Second, "error")
 		return
 	}
 
-	// Convert YAML map to JSON-compatible map
-	jsonMap := convertToStringMap(yamlData)
-
-	// Handle duration fields before JSON conversion
-	if ackWait, ok := jsonMap["ack_wait"].(string); ok {
-		duration, err := time.ParseDuration(ackWait)
-		if err != nil {
-			cap.notify("Invalid ack_wait duration: "+err.Error(), 3*time.Second, "error")
-			return
-		}
-		jsonMap["ack_wait"] = duration.Nanoseconds()
-	}
-	if idleHeartbeat, ok := jsonMap["idle_heartbeat"].(string); ok {
-		duration, err := time.ParseDuration(idleHeartbeat)
-		if err != nil {
-			cap.notify("Invalid idle_heartbeat duration: "+err.Error(), 3*time.Second, "error")
-			return
-		}
-		jsonMap["idle_heartbeat"] = duration.Nanoseconds()
+	wire, config, err := consumerConfigFromValues(values)
+	if err != nil {
+		cap.notify(err.Error(), 3*time.Second, "error")
+		return
 	}
+	pauseUntil, setPause := wire["pause_until"]
 
-	// Convert numeric fields to proper types
-	if sampleFreq, ok := jsonMap["sample_freq"].(int); ok {
-		jsonMap["sample_freq"] = strconv.Itoa(sampleFreq)
+	if !cap.isEdit {
+		cap.submitConsumer(js, config, pauseUntil, setPause && pauseUntil != nil)
+		return
 	}
 
-	// Convert to JSON
-	jsonBytes, err := json.Marshal(jsonMap)
+	// Review the changes against the live configuration before updating
+	config.Name = cap.consumerName
+	live, err := js.ConsumerInfo(cap.streamName, cap.consumerName)
 	if err != nil {
-		cap.notify("Failed to process configuration: "+err.Error(), 3*time.Second, "error")
+		cap.notify("Failed to get consumer info: "+err.Error(), 3*time.Second, "error")
 		return
 	}
-
-	// Parse JSON into consumer config to use NATS struct tags
-	var config nats.ConsumerConfig
-	if err := json.Unmarshal(jsonBytes, &config); err != nil {
-		cap.notify("Invalid configuration: "+err.Error(), 3*time.Second, "error")
+	var liveMap, newMap map[string]interface{}
+	liveBytes, _ := json.Marshal(live.Config)
+	newBytes, _ := json.Marshal(config)
+	json.Unmarshal(liveBytes, &liveMap)
+	json.Unmarshal(newBytes, &newMap)
+	livePause, err := livePauseUntil(cap.Data.CurrCtx.Conn, cap.streamName, cap.consumerName)
+	if err != nil {
+		cap.notify("Failed to get consumer info: "+err.Error(), 3*time.Second, "error")
 		return
 	}
+	// Leaving pause_until out resumes a paused consumer
+	if livePause != nil || pauseUntil != nil {
+		liveMap["pause_until"] = livePause
+		newMap["pause_until"] = pauseUntil
+	}
+	changes := diffConfigs(liveMap, newMap, consumerImmutableFields)
+	if len(changes) == 0 {
+		cap.notify("No changes to save", 3*time.Second, "info")
+		return
+	}
+	pauseChanged := fmt.Sprint(livePause) != fmt.Sprint(pauseUntil)
+	showConfigDiffModal("Update consumer "+cap.consumerName, changes, func() {
+		cap.submitConsumer(js, config, pauseUntil, pauseChanged)
+	})
+}
 
-	// Create or update the consumer
+// submitConsumer creates or updates the consumer, then pauses or resumes it
+// when setPause is set
+func (cap *ConsumerAddPage) submitConsumer(js nats.JetStreamContext, config *nats.ConsumerConfig, pauseUntil interface{}, setPause bool) {
 	var consumer *nats.ConsumerInfo
+	var err error
 	if cap.isEdit {
-		config.Name = cap.consumerName
-		consumer, err = js.UpdateConsumer(cap.streamName, &config)
+		consumer, err = js.UpdateConsumer(cap.streamName, config)
 	} else {
-		consumer, err = js.AddConsumer(cap.streamName, &config)
+		consumer, err = js.AddConsumer(cap.streamName, config)
 	}
 
 	if err != nil {
@@ -265,12 +220,127 @@ func (cap *ConsumerAddPage) saveConsumer() {
 		return
 	}
 
+	if setPause {
+		if err := pauseConsumer(cap.Data.CurrCtx.Conn, cap.streamName, consumer.Name, pauseUntil); err != nil {
+			cap.notify("Consumer "+consumer.Name+" saved, but pausing failed: "+err.Error(), 5*time.Second, "error")
+			return
+		}
+	}
+
 	cap.notify("Consumer "+consumer.Name+" saved successfully", 3*time.Second, "info")
-	
+
 	// Switch back to consumer list
 	cap.goBack()
 }
 
+// consumerConfigFromValues converts the editor values to the wire map and the
+// consumer configuration. Durations are written like "30s".
+func consumerConfigFromValues(values map[string]interface{}) (map[string]interface{}, *nats.ConsumerConfig, error) {
+	// Unknown keys would be dropped silently by json.Unmarshal
+	known := make(map[string]bool, len(consumerConfigFields))
+	for _, f := range consumerConfigFields {
+		known[f.key] = true
+	}
+	unknown := make([]string, 0)
+	for k := range values {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, nil, fmt.Errorf("unknown consumer keys: %s", strings.Join(unknown, ", "))
+	}
+
+	config := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		config[k] = v
+	}
+	if err := durationStringsToNanos(config, consumerDurationFields); err != nil {
+		return nil, nil, err
+	}
+	if err := backoffStringsToNanos(config); err != nil {
+		return nil, nil, err
+	}
+	if v, ok := config["pause_until"]; ok {
+		until, err := parsePauseUntil(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		config["pause_until"] = formatPauseUntil(until)
+	}
+
+	// Convert numeric fields to proper types
+	switch sampleFreq := config["sample_freq"].(type) {
+	case int:
+		config["sample_freq"] = strconv.Itoa(sampleFreq)
+	case float64:
+		config["sample_freq"] = strconv.Itoa(int(sampleFreq))
+	}
+
+	// Convert to JSON
+	jsonBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to process configuration: %v", err)
+	}
+
+	// Parse JSON into consumer config to use NATS struct tags
+	var consumerConfig nats.ConsumerConfig
+	if err := json.Unmarshal(jsonBytes, &consumerConfig); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	if err := checkConsumerConfig(&consumerConfig); err != nil {
+		return nil, nil, err
+	}
+	return config, &consumerConfig, nil
+}
+
+// checkConsumerConfig catches combinations the server would reject with a
+// less helpful error
+func checkConsumerConfig(c *nats.ConsumerConfig) error {
+	switch {
+	case c.DeliverPolicy == nats.DeliverByStartSequencePolicy && c.OptStartSeq == 0:
+		return fmt.Errorf("deliver_policy by_start_sequence requires opt_start_seq")
+	case c.DeliverPolicy != nats.DeliverByStartSequencePolicy && c.OptStartSeq != 0:
+		return fmt.Errorf("opt_start_seq requires deliver_policy by_start_sequence")
+	case c.DeliverPolicy == nats.DeliverByStartTimePolicy && c.OptStartTime == nil:
+		return fmt.Errorf("deliver_policy by_start_time requires opt_start_time")
+	case c.DeliverPolicy != nats.DeliverByStartTimePolicy && c.OptStartTime != nil:
+		return fmt.Errorf("opt_start_time requires deliver_policy by_start_time")
+	}
+
+	// A deliver subject makes a push consumer, without one it is pulled
+	if c.DeliverSubject != "" {
+		pullOnly := map[string]bool{
+			"max_waiting": c.MaxWaiting != 0,
+			"max_batch":   c.MaxRequestBatch != 0,
+			"max_expires": c.MaxRequestExpires != 0,
+			"max_bytes":   c.MaxRequestMaxBytes != 0,
+		}
+		for _, key := range []string{"max_waiting", "max_batch", "max_expires", "max_bytes"} {
+			if pullOnly[key] {
+				return fmt.Errorf("%s is only valid for pull consumers, remove it or deliver_subject", key)
+			}
+		}
+		if c.FlowControl && c.Heartbeat == 0 {
+			return fmt.Errorf("flow_control requires idle_heartbeat")
+		}
+		return nil
+	}
+	pushOnly := map[string]bool{
+		"deliver_group":  c.DeliverGroup != "",
+		"flow_control":   c.FlowControl,
+		"idle_heartbeat": c.Heartbeat != 0,
+		"rate_limit_bps": c.RateLimit != 0,
+	}
+	for _, key := range []string{"deliver_group", "flow_control", "idle_heartbeat", "rate_limit_bps"} {
+		if pushOnly[key] {
+			return fmt.Errorf("%s is only valid for push consumers, set deliver_subject", key)
+		}
+	}
+	return nil
+}
+
 // Helper function to convert YAML map to JSON-compatible map
 func convertToStringMap(m map[interface{}]interface{}) map[string]interface{} {
 	res := make(map[string]interface{})
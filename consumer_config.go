@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/solidpulse/natsdash/natsutil"
+)
+
+// consumerConfigFields lists every nats.ConsumerConfig field in editor order
+var consumerConfigFields = []configField{
+	{"name", []string{"Name of the consumer"}, ""},
+	{"durable_name", []string{"Durable name, the consumer survives client restarts"}, ""},
+	{"description", []string{"Description of the consumer (optional)"}, ""},
+	{"deliver_policy", []string{"Where delivery starts", `Possible values: "all", "last", "new", "by_start_sequence", "by_start_time", "last_per_subject"`}, "all"},
+	{"opt_start_seq", []string{"Start sequence, requires deliver_policy \"by_start_sequence\""}, 0},
+	{"opt_start_time", []string{"Start time in RFC3339, requires deliver_policy \"by_start_time\""}, nil},
+	{"ack_policy", []string{"Acknowledgment policy", `Possible values: "none", "all", "explicit"`}, "explicit"},
+	{"ack_wait", []string{"How long to wait for an ack before redelivery", `Examples: "30s", "1m"`}, "30s"},
+	{"max_deliver", []string{"Maximum delivery attempts", "-1 for unlimited"}, -1},
+	{"backoff", []string{"Redelivery delays, overrides ack_wait", `Example: ["1s", "10s", "1m"]`}, nil},
+	{"filter_subject", []string{"Only deliver messages matching this subject"}, ""},
+	{"filter_subjects", []string{"Only deliver messages matching one of these subjects"}, nil},
+	{"replay_policy", []string{"Replay speed", `Possible values: "instant", "original"`}, "instant"},
+	{"rate_limit_bps", []string{"Delivery rate limit in bits per second, push consumers only"}, 0},
+	{"sample_freq", []string{"Percentage of acks sampled for observability, e.g. \"100%\""}, ""},
+	{"max_waiting", []string{"Maximum outstanding pull requests, pull consumers only"}, 512},
+	{"max_ack_pending", []string{"Maximum messages delivered but not acknowledged", "-1 for unlimited"}, 1000},
+	{"flow_control", []string{"Enable flow control, push consumers only"}, false},
+	{"idle_heartbeat", []string{"Heartbeat interval while idle, push consumers only"}, "0s"},
+	{"headers_only", []string{"Deliver only the headers and the payload size"}, false},
+	{"max_batch", []string{"Maximum batch size of a pull request, pull consumers only"}, 0},
+	{"max_expires", []string{"Maximum expiry of a pull request, pull consumers only"}, "0s"},
+	{"max_bytes", []string{"Maximum bytes of a pull request, pull consumers only"}, 0},
+	{"deliver_subject", []string{"Subject to push messages to, makes this a push consumer", "Leave empty for a pull consumer"}, ""},
+	{"deliver_group", []string{"Queue group of push consumer subscribers, requires deliver_subject"}, ""},
+	{"inactive_threshold", []string{"Remove the consumer after this long without activity"}, "0s"},
+	{"num_replicas", []string{"Number of replicas, 0 to inherit from the stream"}, 0},
+	{"mem_storage", []string{"Keep the consumer state in memory"}, false},
+	{"pause_until", []string{"Pause deliveries until this RFC3339 time, null resumes", "Requires nats-server 2.11"}, nil},
+	{"metadata", []string{"Application defined key/value pairs"}, map[string]interface{}{}},
+}
+
+// newConsumerConfigValues is the starting point for new consumers, a durable
+// pull consumer. Setting deliver_subject turns it into a push consumer.
+func newConsumerConfigValues() map[string]interface{} {
+	return map[string]interface{}{
+		"durable_name":    "NEW",
+		"description":     "",
+		"deliver_policy":  "all",
+		"opt_start_seq":   0,
+		"opt_start_time":  nil,
+		"ack_policy":      "explicit",
+		"ack_wait":        "30s",
+		"max_deliver":     -1,
+		"filter_subject":  "",
+		"replay_policy":   "instant",
+		"max_waiting":     512,
+		"max_ack_pending": 1000,
+		"deliver_subject": "",
+		"deliver_group":   "",
+	}
+}
+
+// consumerEditorValues turns the durations of a consumer config map, as the
+// server reports them in nanoseconds, into strings like "30s"
+func consumerEditorValues(config map[string]interface{}) map[string]interface{} {
+	nanosToDurationStrings(config, consumerDurationFields)
+	if backoff, ok := config["backoff"].([]interface{}); ok {
+		for i, v := range backoff {
+			if n, ok := v.(float64); ok {
+				backoff[i] = formatHumanDuration(time.Duration(int64(n)))
+			}
+		}
+	}
+	return config
+}
+
+// backoffStringsToNanos converts the backoff durations written like "30s"
+func backoffStringsToNanos(config map[string]interface{}) error {
+	backoff, ok := config["backoff"].([]interface{})
+	if !ok {
+		return nil
+	}
+	nanos := make([]interface{}, len(backoff))
+	for i, v := range backoff {
+		nanos[i] = v
+		if s, ok := v.(string); ok {
+			d, err := parseHumanDuration(s)
+			if err != nil {
+				return fmt.Errorf("backoff: invalid duration %q", s)
+			}
+			nanos[i] = d.Nanoseconds()
+		}
+	}
+	config["backoff"] = nanos
+	return nil
+}
+
+// parsePauseUntil reads a pause_until value, null means not paused. The field
+// isn't part of nats.ConsumerConfig and is set through the pause API.
+func parsePauseUntil(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case string:
+		if t == "" {
+			return time.Time{}, nil
+		}
+		until, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("pause_until: invalid time %q, expected RFC3339", t)
+		}
+		return until, nil
+	case time.Time:
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("pause_until: expected an RFC3339 time")
+}
+
+// formatPauseUntil is the normalized pause_until value
+func formatPauseUntil(until time.Time) interface{} {
+	if until.IsZero() {
+		return nil
+	}
+	return until.UTC().Format(time.RFC3339Nano)
+}
+
+// livePauseUntil returns the normalized pause_until of an existing consumer,
+// nil when it isn't paused or the server doesn't support pausing
+func livePauseUntil(nc *nats.Conn, stream string, consumer string) (interface{}, error) {
+	info, err := natsutil.ConsumerInfoRaw(nc, stream, consumer)
+	if err != nil {
+		return nil, err
+	}
+	config, _ := info["config"].(map[string]interface{})
+	until, err := parsePauseUntil(config["pause_until"])
+	if err != nil {
+		return nil, err
+	}
+	return formatPauseUntil(until), nil
+}
+
+// pauseConsumer applies a pause_until value to a consumer
+func pauseConsumer(nc *nats.Conn, stream string, consumer string, pauseUntil interface{}) error {
+	until, err := parsePauseUntil(pauseUntil)
+	if err != nil {
+		return err
+	}
+	return natsutil.PauseConsumer(nc, stream, consumer, until)
+}
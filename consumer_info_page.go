@@ -1,13 +1,13 @@
 package main
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
 	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
 	"gopkg.in/yaml.v2"
 )
 
@@ -52,41 +52,19 @@ func NewConsumerInfoPage(app *tview.Application, data *ds.Data) *ConsumerInfoPag
 func (cip *ConsumerInfoPage) redraw(ctx *ds.Context) {
 	cip.txtArea.SetTitle("Consumer Info: " + cip.consumerName)
 
-	// Get JetStream context
-	js, err := ctx.Conn.JetStream()
-	if err != nil {
-		cip.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
-		return
-	}
-
-	// Get consumer info
-	consumer, err := js.ConsumerInfo(cip.streamName, cip.consumerName)
+	// Get consumer info, raw so fields unknown to nats.go like the pause show up
+	infoMap, err := natsutil.ConsumerInfoRaw(ctx.Conn, cip.streamName, cip.consumerName)
 	if err != nil {
 		cip.notify("Failed to get consumer info: "+err.Error(), 3*time.Second, "error")
 		return
 	}
-
-	// Convert to map first
-	var infoMap map[string]interface{}
-	jsonBytes, err := json.Marshal(consumer)
-	if err != nil {
-		cip.notify("Failed to convert info: "+err.Error(), 3*time.Second, "error")
-		return
-	}
-	if err := json.Unmarshal(jsonBytes, &infoMap); err != nil {
-		cip.notify("Failed to process info: "+err.Error(), 3*time.Second, "error")
-		return
-	}
+	delete(infoMap, "type")
 
 	// Convert duration fields to strings
 	if config, ok := infoMap["config"].(map[string]interface{}); ok {
-		if ackWait, ok := config["ack_wait"].(float64); ok {
-			config["ack_wait"] = time.Duration(ackWait).String()
-		}
-		if idleHeartbeat, ok := config["idle_heartbeat"].(float64); ok {
-			config["idle_heartbeat"] = time.Duration(idleHeartbeat).String()
-		}
+		consumerEditorValues(config)
 	}
+	nanosToDurationStrings(infoMap, []string{"pause_remaining"})
 
 	// Convert to YAML
 	yamlBytes, err := yaml.Marshal(infoMap)
@@ -1,23 +1,150 @@
 package main
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
 	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
 )
 
 type ConsumerListPage struct {
 	*tview.Flex
-	Data           *ds.Data
-	consumerList   *tview.List
-	app            *tview.Application
-	footerTxt      *tview.TextView
-	streamName     string
+	Data                  *ds.Data
+	consumerTable         *tview.Table
+	tableBox              *tview.Flex
+	app                   *tview.Application
+	footerTxt             *tview.TextView
+	streamName            string
 	deleteConfirmConsumer string
 	deleteConfirmTimer    *time.Timer
+	consumers             []*nats.ConsumerInfo
+	sortCol               int
+	sortDesc              bool
+	refreshGen            int
+}
+
+const (
+	consumerListRefreshInterval = 5 * time.Second
+	// consumers with more unprocessed messages than this are flagged
+	consumerPendingThreshold = 10000
+	// outstanding acks older than this count as a stalled ack floor
+	consumerStallThreshold = time.Minute
+)
+
+// consumerColumn is a column of the consumer table
+type consumerColumn struct {
+	title string
+	value func(c *nats.ConsumerInfo) string
+	less  func(a, b *nats.ConsumerInfo) bool
+	right bool // right aligned
+}
+
+var consumerColumns = []consumerColumn{
+	{"Name", func(c *nats.ConsumerInfo) string { return c.Name },
+		func(a, b *nats.ConsumerInfo) bool { return a.Name < b.Name }, false},
+	{"Type", consumerType,
+		func(a, b *nats.ConsumerInfo) bool { return consumerType(a) < consumerType(b) }, false},
+	{"Filter", consumerFilterText,
+		func(a, b *nats.ConsumerInfo) bool { return consumerFilterText(a) < consumerFilterText(b) }, false},
+	{"Ack", func(c *nats.ConsumerInfo) string {
+		return strings.ToLower(strings.TrimPrefix(c.Config.AckPolicy.String(), "Ack"))
+	},
+		func(a, b *nats.ConsumerInfo) bool { return a.Config.AckPolicy < b.Config.AckPolicy }, false},
+	{"Pending", func(c *nats.ConsumerInfo) string { return humanCount(c.NumPending) },
+		func(a, b *nats.ConsumerInfo) bool { return a.NumPending < b.NumPending }, true},
+	{"Ack Pending", func(c *nats.ConsumerInfo) string { return strconv.Itoa(c.NumAckPending) },
+		func(a, b *nats.ConsumerInfo) bool { return a.NumAckPending < b.NumAckPending }, true},
+	{"Redelivered", func(c *nats.ConsumerInfo) string { return strconv.Itoa(c.NumRedelivered) },
+		func(a, b *nats.ConsumerInfo) bool { return a.NumRedelivered < b.NumRedelivered }, true},
+	{"Waiting", func(c *nats.ConsumerInfo) string { return strconv.Itoa(c.NumWaiting) },
+		func(a, b *nats.ConsumerInfo) bool { return a.NumWaiting < b.NumWaiting }, true},
+	{"Delivered", func(c *nats.ConsumerInfo) string { return strconv.FormatUint(c.Delivered.Stream, 10) },
+		func(a, b *nats.ConsumerInfo) bool { return a.Delivered.Stream < b.Delivered.Stream }, true},
+	{"Ack Floor", func(c *nats.ConsumerInfo) string { return strconv.FormatUint(c.AckFloor.Stream, 10) },
+		func(a, b *nats.ConsumerInfo) bool { return a.AckFloor.Stream < b.AckFloor.Stream }, true},
+	{"Last Active", func(c *nats.ConsumerInfo) string { return humanAgo(consumerLastActive(c)) },
+		func(a, b *nats.ConsumerInfo) bool { return consumerLastActive(a).Before(consumerLastActive(b)) }, true},
+	{"Health", func(c *nats.ConsumerInfo) string { return consumerHealthText(consumerProblems(c)) },
+		func(a, b *nats.ConsumerInfo) bool { return len(consumerProblems(a)) < len(consumerProblems(b)) }, false},
+}
+
+// consumerPendingCol is the default sort, the consumers lagging most first
+const consumerPendingCol = 4
+
+func consumerType(c *nats.ConsumerInfo) string {
+	kind := "pull"
+	if c.Config.DeliverSubject != "" {
+		kind = "push"
+	}
+	if c.Config.Durable != "" {
+		return kind + "/durable"
+	}
+	return kind + "/ephemeral"
+}
+
+func consumerFilterText(c *nats.ConsumerInfo) string {
+	if len(c.Config.FilterSubjects) > 0 {
+		return strings.Join(c.Config.FilterSubjects, ", ")
+	}
+	return c.Config.FilterSubject
+}
+
+// consumerLastActive is the time of the last delivery, zero when never delivered
+func consumerLastActive(c *nats.ConsumerInfo) time.Time {
+	if c.Delivered.Last == nil {
+		return time.Time{}
+	}
+	return *c.Delivered.Last
+}
+
+// consumerProblems lists why a consumer looks unhealthy
+func consumerProblems(c *nats.ConsumerInfo) []string {
+	problems := make([]string, 0)
+	if c.NumPending > consumerPendingThreshold {
+		problems = append(problems, "lagging")
+	}
+	if c.NumAckPending > 0 {
+		lastAck := c.AckFloor.Last
+		if lastAck == nil {
+			lastAck = c.Delivered.Last
+		}
+		if lastAck != nil && time.Since(*lastAck) > consumerStallThreshold {
+			problems = append(problems, "ack floor stalled")
+		}
+	}
+	switch {
+	case c.Config.DeliverSubject != "" && !c.PushBound:
+		problems = append(problems, "no interest")
+	case c.Config.DeliverSubject == "" && c.NumPending > 0 && c.NumWaiting == 0 && deliveryStalled(c):
+		// Workers fetch in rounds, only a lasting gap means nobody is pulling
+		problems = append(problems, "no pull requests")
+	}
+	return problems
+}
+
+// deliveryStalled reports whether nothing was delivered for longer than
+// consumerStallThreshold, or ever since the consumer was created that long ago
+func deliveryStalled(c *nats.ConsumerInfo) bool {
+	last := consumerLastActive(c)
+	if last.IsZero() {
+		last = c.Created
+	}
+	return time.Since(last) > consumerStallThreshold
+}
+
+func consumerHealthText(problems []string) string {
+	if len(problems) == 0 {
+		return "ok"
+	}
+	return strings.Join(problems, ", ")
 }
 
 func NewConsumerListPage(app *tview.Application, data *ds.Data) *ConsumerListPage {
@@ -29,20 +156,21 @@ func NewConsumerListPage(app *tview.Application, data *ds.Data) *ConsumerListPag
 
 	// Create header
 	headerRow2 := tview.NewFlex().SetDirection(tview.FlexRow)
-	txtViewHeader := createTextView("[ESC] Back [a] Add [e] Edit [i] Info [d] Delete", tcell.ColorWhite)
+	txtViewHeader := createTextView("[ESC] Back [a] Add [e] Edit [i] Info [w] Workbench [d] Delete [</>] Sort Column [s] Sort Order", tcell.ColorWhite)
 	txtViewHeader.SetBorderPadding(1,1,1,1)
 	headerRow2.AddItem(txtViewHeader, 0, 1, false)
 
-	// Create consumer list
-	cp.consumerList = tview.NewList().
-		ShowSecondaryText(false).
-		SetHighlightFullLine(true).
-		SetMainTextColor(tcell.ColorWhite).
-		SetSelectedTextColor(tcell.ColorBlack).
-		SetSelectedBackgroundColor(tcell.ColorWhite)
-	cp.consumerList.SetBorder(true)
-	cp.consumerList.SetBorderPadding(0, 0, 1, 1)
-	cp.consumerList.SetTitle("Consumers")
+	// Create consumer table
+	cp.tableBox = tview.NewFlex()
+	cp.tableBox.SetBorder(true)
+	cp.tableBox.SetBorderPadding(0, 0, 1, 1)
+	cp.tableBox.SetTitle("Consumers")
+	cp.consumerTable = tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 1)
+	cp.tableBox.AddItem(cp.consumerTable, 0, 1, true)
+	cp.sortCol = consumerPendingCol
+	cp.sortDesc = true
 
 	// Create footer
 	cp.footerTxt = createTextView("", tcell.ColorWhite)
@@ -51,7 +179,7 @@ func NewConsumerListPage(app *tview.Application, data *ds.Data) *ConsumerListPag
 	// Add all components
 	cp.
 		AddItem(headerRow2, 3, 0, false).
-		AddItem(cp.consumerList, 0, 1, true).
+		AddItem(cp.tableBox, 0, 1, true).
 		AddItem(cp.footerTxt, 3, 0, false)
 
 	cp.setupInputCapture()
@@ -67,6 +195,19 @@ func (cp *ConsumerListPage) setupInputCapture() {
 			return nil
 		default:
 			switch event.Rune() {
+			case '<', '>':
+				// Move the sort to the previous or next column
+				delta := 1
+				if event.Rune() == '<' {
+					delta = len(consumerColumns) - 1
+				}
+				cp.sortCol = (cp.sortCol + delta) % len(consumerColumns)
+				cp.renderTable()
+				return nil
+			case 's', 'S':
+				cp.sortDesc = !cp.sortDesc
+				cp.renderTable()
+				return nil
 			case 'a', 'A':
 				logger.Info("Add consumer action triggered")
 				pages.SwitchToPage("consumerAddPage")
@@ -76,12 +217,11 @@ func (cp *ConsumerListPage) setupInputCapture() {
 				addPage.isEdit = false	
 				addPage.redraw(&cp.Data.CurrCtx)
 			case 'e', 'E':
-				if cp.consumerList.GetItemCount() == 0 {
+				consumerName, ok := cp.selectedConsumer()
+				if !ok {
 					cp.notify("No consumer selected", 3*time.Second, "error")
 					return event
 				}
-				idx := cp.consumerList.GetCurrentItem()
-				consumerName, _ := cp.consumerList.GetItemText(idx)
 				logger.Info("Add consumer action triggered")
 				pages.SwitchToPage("consumerAddPage")
 				_, b := pages.GetFrontPage()
@@ -91,12 +231,11 @@ func (cp *ConsumerListPage) setupInputCapture() {
 				editPage.consumerName = consumerName
 				editPage.redraw(&cp.Data.CurrCtx)
 			case 'd', 'D':
-				if cp.consumerList.GetItemCount() == 0 {
+				consumerName, ok := cp.selectedConsumer()
+				if !ok {
 					cp.notify("No consumer selected", 3*time.Second, "error")
 					return event
 				}
-				idx := cp.consumerList.GetCurrentItem()
-				consumerName, _ := cp.consumerList.GetItemText(idx)
 				
 				if cp.deleteConfirmConsumer == consumerName {
 					// Second press - execute delete
@@ -109,13 +248,24 @@ func (cp *ConsumerListPage) setupInputCapture() {
 					cp.startDeleteConfirmation(consumerName)
 				}
 				return nil
+			case 'w', 'W':
+				consumerName, ok := cp.selectedConsumer()
+				if !ok {
+					cp.notify("No consumer selected", 3*time.Second, "error")
+					return event
+				}
+				logger.Info("Consumer workbench triggered for: %s", consumerName)
+				pages.SwitchToPage("consumerWorkbenchPage")
+				_, b := pages.GetFrontPage()
+				workbenchPage := b.(*ConsumerWorkbenchPage)
+				workbenchPage.open(&cp.Data.CurrCtx, cp.streamName, consumerName)
+				return nil
 			case 'i', 'I':
-				if cp.consumerList.GetItemCount() == 0 {
+				consumerName, ok := cp.selectedConsumer()
+				if !ok {
 					cp.notify("No consumer selected", 3*time.Second, "error")
 					return event
 				}
-				idx := cp.consumerList.GetCurrentItem()
-				consumerName, _ := cp.consumerList.GetItemText(idx)
 				logger.Info("Consumer info action triggered for: %s", consumerName)
 				pages.SwitchToPage("consumerInfoPage")
 				_, b := pages.GetFrontPage()
@@ -156,24 +306,113 @@ func (cp *ConsumerListPage) notify(message string, duration time.Duration, logLe
 	}()
 }
 
+// redraw loads the consumers in the background and keeps refreshing them
+// while the page is visible
 func (cp *ConsumerListPage) redraw(ctx *ds.Context) {
-	cp.consumerList.Clear()
+	cp.refreshGen++
+	gen := cp.refreshGen
+	conn := ctx.Conn
+	streamName := cp.streamName
+	cp.tableBox.SetTitle("Consumers of " + streamName)
+	cp.app.SetFocus(cp.consumerTable)
 
-	// Get JetStream context
-	js, err := ctx.Conn.JetStream()
-	if err != nil {
-		cp.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
-		return
+	go func() {
+		for {
+			consumers, err := natsutil.ListConsumers(conn, streamName)
+			keepGoing := make(chan bool, 1)
+			cp.app.QueueUpdateDraw(func() {
+				if gen != cp.refreshGen {
+					keepGoing <- false
+					return
+				}
+				if err != nil {
+					logger.Error("Failed to list consumers: %v", err)
+					cp.notify("Failed to list consumers: "+err.Error(), 3*time.Second, "error")
+				} else {
+					cp.consumers = consumers
+					cp.renderTable()
+				}
+				keepGoing <- isPageVisible("consumerListPage")
+			})
+			if !<-keepGoing {
+				return
+			}
+			time.Sleep(consumerListRefreshInterval)
+		}
+	}()
+}
+
+func (cp *ConsumerListPage) renderTable() {
+	selected, _ := cp.selectedConsumer()
+
+	col := consumerColumns[cp.sortCol]
+	sort.SliceStable(cp.consumers, func(i, j int) bool {
+		if cp.sortDesc {
+			return col.less(cp.consumers[j], cp.consumers[i])
+		}
+		return col.less(cp.consumers[i], cp.consumers[j])
+	})
+
+	cp.consumerTable.Clear()
+	for c, column := range consumerColumns {
+		title := column.title
+		if c == cp.sortCol {
+			if cp.sortDesc {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		cell := tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1)
+		if column.right {
+			cell.SetAlign(tview.AlignRight)
+		}
+		cp.consumerTable.SetCell(0, c, cell)
 	}
 
-	consumersChan := js.Consumers(cp.streamName)
+	selectedRow := 1
+	unhealthy := 0
+	for r, info := range cp.consumers {
+		color := tcell.ColorWhite
+		if len(consumerProblems(info)) > 0 {
+			color = tcell.ColorRed
+			unhealthy++
+		}
+		for c, column := range consumerColumns {
+			cell := tview.NewTableCell(" " + column.value(info) + " ").
+				SetMaxWidth(40).
+				SetTextColor(color)
+			if column.right {
+				cell.SetAlign(tview.AlignRight)
+			}
+			cp.consumerTable.SetCell(r+1, c, cell)
+		}
+		if info.Name == selected {
+			selectedRow = r + 1
+		}
+	}
+	if len(cp.consumers) > 0 {
+		cp.consumerTable.Select(selectedRow, 0)
+	}
 
-	for consumer := range consumersChan {
-		cp.consumerList.AddItem(consumer.Name, "", 0, nil)
+	title := fmt.Sprintf("Consumers of %s (%d)", cp.streamName, len(cp.consumers))
+	if unhealthy > 0 {
+		title = fmt.Sprintf("Consumers of %s (%d, %d unhealthy)", cp.streamName, len(cp.consumers), unhealthy)
 	}
-	
+	cp.tableBox.SetTitle(title)
 }
 
+// selectedConsumer returns the name of the consumer under the cursor
+func (cp *ConsumerListPage) selectedConsumer() (string, bool) {
+	row, _ := cp.consumerTable.GetSelection()
+	if row < 1 || row > len(cp.consumers) {
+		return "", false
+	}
+	return cp.consumers[row-1].Name, true
+}
 
 func (cp *ConsumerListPage) deleteConsumer(consumerName string) {
 	// Get JetStream context
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+)
+
+// workbenchMsg is a fetched message together with what was done with it
+type workbenchMsg struct {
+	msg    *nats.Msg
+	meta   *nats.MsgMetadata
+	status string
+}
+
+// ConsumerWorkbenchPage fetches batches from a durable pull consumer and lets
+// every message be acked, nak'd, marked in progress or terminated by hand
+type ConsumerWorkbenchPage struct {
+	*tview.Flex
+	Data         *ds.Data
+	app          *tview.Application
+	batchInput   *tview.InputField
+	expiryInput  *tview.InputField
+	maxBytesIn   *tview.InputField
+	msgTable     *tview.Table
+	tableBox     *tview.Flex
+	detailView   *tview.TextView
+	footerTxt    *tview.TextView
+	streamName   string
+	consumerName string
+	sub          *nats.Subscription
+	msgs         []*workbenchMsg
+	fetching     bool
+}
+
+func NewConsumerWorkbenchPage(app *tview.Application, data *ds.Data) *ConsumerWorkbenchPage {
+	cwp := &ConsumerWorkbenchPage{
+		Flex: tview.NewFlex().SetDirection(tview.FlexRow),
+		app:  app,
+		Data: data,
+	}
+	cwp.setupUI()
+	cwp.setupInputCapture()
+	return cwp
+}
+
+func (cwp *ConsumerWorkbenchPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back\n[o] Fetch Options", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[f] Fetch Batch\n[c] Clear Handled", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[a] Ack\n[n] Nak (with delay)", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[p] In Progress\n[t] Term (with reason)", tcell.ColorWhite), 0, 1, false)
+	cwp.AddItem(headerRow, 4, 0, false)
+
+	// Fetch options
+	cwp.batchInput = tview.NewInputField().
+		SetLabel("Batch: ").
+		SetText("10").
+		SetFieldWidth(8).
+		SetAcceptanceFunc(tview.InputFieldInteger).
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	cwp.expiryInput = tview.NewInputField().
+		SetLabel("Expiry: ").
+		SetText("5s").
+		SetFieldWidth(10).
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	cwp.maxBytesIn = tview.NewInputField().
+		SetLabel("Max Bytes: ").
+		SetText("0").
+		SetFieldWidth(12).
+		SetAcceptanceFunc(tview.InputFieldInteger).
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	inputs := []*tview.InputField{cwp.batchInput, cwp.expiryInput, cwp.maxBytesIn}
+	for i, input := range inputs {
+		next := inputs[(i+1)%len(inputs)]
+		input.SetDoneFunc(func(key tcell.Key) {
+			switch key {
+			case tcell.KeyTab:
+				cwp.app.SetFocus(next)
+			case tcell.KeyEnter:
+				cwp.app.SetFocus(cwp.msgTable)
+				cwp.fetch()
+			default:
+				cwp.app.SetFocus(cwp.msgTable)
+			}
+		})
+	}
+	optionsRow := tview.NewFlex().SetDirection(tview.FlexColumn)
+	optionsRow.SetBorderPadding(0, 0, 1, 1)
+	optionsRow.AddItem(cwp.batchInput, 0, 1, false)
+	optionsRow.AddItem(cwp.expiryInput, 0, 1, false)
+	optionsRow.AddItem(cwp.maxBytesIn, 0, 1, false)
+	cwp.AddItem(optionsRow, 1, 0, false)
+
+	// Message table
+	cwp.tableBox = tview.NewFlex()
+	cwp.tableBox.SetBorder(true)
+	cwp.tableBox.SetBorderPadding(0, 0, 1, 1)
+	cwp.msgTable = tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	cwp.msgTable.SetSelectionChangedFunc(func(row, column int) {
+		cwp.showDetail()
+	})
+	cwp.tableBox.AddItem(cwp.msgTable, 0, 1, true)
+	cwp.AddItem(cwp.tableBox, 0, 3, true)
+
+	// Message inspector
+	cwp.detailView = tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
+	cwp.detailView.SetBorder(true)
+	cwp.detailView.SetTitle("Message")
+	cwp.detailView.SetBorderPadding(0, 0, 1, 1)
+	cwp.AddItem(cwp.detailView, 0, 2, false)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	cwp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(cwp.footerTxt, 0, 1, false)
+	cwp.AddItem(footer, 3, 0, false)
+
+	cwp.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (cwp *ConsumerWorkbenchPage) setupInputCapture() {
+	cwp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if cwp.batchInput.HasFocus() || cwp.expiryInput.HasFocus() || cwp.maxBytesIn.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEsc:
+			cwp.goBack()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'o':
+				cwp.app.SetFocus(cwp.batchInput)
+				return nil
+			case 'f':
+				cwp.fetch()
+				return nil
+			case 'c':
+				cwp.clearHandled()
+				return nil
+			case 'a':
+				cwp.respond("acked", func(m *nats.Msg) error { return m.AckSync() })
+				return nil
+			case 'n':
+				showPromptModal("Nak", "Redeliver after (empty for now): ", "", func(text string) {
+					text = strings.TrimSpace(text)
+					if text == "" {
+						cwp.respond("nak'd", func(m *nats.Msg) error { return m.Nak() })
+						return
+					}
+					delay, err := parseHumanDuration(text)
+					if err != nil {
+						cwp.notify("Invalid delay: "+err.Error(), 3*time.Second, "error")
+						return
+					}
+					cwp.respond("nak'd +"+formatHumanDuration(delay), func(m *nats.Msg) error {
+						return m.NakWithDelay(delay)
+					})
+				})
+				return nil
+			case 'p':
+				cwp.respond("in progress", func(m *nats.Msg) error { return m.InProgress() })
+				return nil
+			case 't':
+				showPromptModal("Term", "Reason (optional): ", "", func(text string) {
+					reason := strings.TrimSpace(text)
+					cwp.respond("termed", func(m *nats.Msg) error { return termWithReason(cwp.Data.CurrCtx.Conn, m, reason) })
+				})
+				return nil
+			}
+		}
+		return event
+	})
+}
+
+// termWithReason terminates the message. The library has no call that takes
+// a reason, so the ack is sent by hand when one is given.
+func termWithReason(nc *nats.Conn, m *nats.Msg, reason string) error {
+	if reason == "" {
+		return m.Term()
+	}
+	if m.Reply == "" {
+		return nats.ErrMsgNoReply
+	}
+	return nc.Publish(m.Reply, []byte("+TERM "+reason))
+}
+
+// open binds to the pull consumer and starts with an empty workbench
+func (cwp *ConsumerWorkbenchPage) open(ctx *ds.Context, streamName, consumerName string) {
+	cwp.close()
+	cwp.streamName = streamName
+	cwp.consumerName = consumerName
+	cwp.msgs = nil
+	cwp.redraw(ctx)
+}
+
+func (cwp *ConsumerWorkbenchPage) redraw(ctx *ds.Context) {
+	cwp.renderTable()
+	cwp.app.SetFocus(cwp.msgTable)
+
+	js, err := ctx.Conn.JetStream()
+	if err != nil {
+		cwp.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	info, err := js.ConsumerInfo(cwp.streamName, cwp.consumerName)
+	if err != nil {
+		cwp.notify("Failed to get consumer info: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	if info.Config.DeliverSubject != "" {
+		cwp.notify("The workbench only works with pull consumers", 5*time.Second, "error")
+		return
+	}
+	if info.Config.Durable == "" {
+		cwp.notify("The workbench only works with durable consumers", 5*time.Second, "error")
+		return
+	}
+	sub, err := js.PullSubscribe("", cwp.consumerName, nats.Bind(cwp.streamName, cwp.consumerName))
+	if err != nil {
+		cwp.notify("Failed to bind to consumer: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	cwp.sub = sub
+	cwp.notify(fmt.Sprintf("Bound to %s, %d pending, %d waiting for ack. Press f to fetch",
+		cwp.consumerName, info.NumPending, info.NumAckPending), 5*time.Second, "info")
+}
+
+// close drops the subscription. Messages still unacked are redelivered by the
+// server once their ack wait runs out.
+func (cwp *ConsumerWorkbenchPage) close() {
+	if cwp.sub != nil {
+		if err := cwp.sub.Unsubscribe(); err != nil {
+			logger.Error("Failed to unsubscribe workbench: %v", err)
+		}
+		cwp.sub = nil
+	}
+}
+
+// fetchOptions reads batch size, expiry and max bytes from the option fields
+func (cwp *ConsumerWorkbenchPage) fetchOptions() (int, []nats.PullOpt, error) {
+	batch, err := strconv.Atoi(strings.TrimSpace(cwp.batchInput.GetText()))
+	if err != nil || batch < 1 {
+		return 0, nil, errors.New("batch must be a positive number")
+	}
+	expiry, err := parseHumanDuration(strings.TrimSpace(cwp.expiryInput.GetText()))
+	if err != nil || expiry <= 0 {
+		return 0, nil, errors.New("expiry must be a duration such as 5s")
+	}
+	opts := []nats.PullOpt{nats.MaxWait(expiry)}
+	if text := strings.TrimSpace(cwp.maxBytesIn.GetText()); text != "" {
+		maxBytes, err := strconv.Atoi(text)
+		if err != nil || maxBytes < 0 {
+			return 0, nil, errors.New("max bytes must be 0 or more")
+		}
+		if maxBytes > 0 {
+			opts = append(opts, nats.PullMaxBytes(maxBytes))
+		}
+	}
+	return batch, opts, nil
+}
+
+// fetch pulls the next batch in the background and appends it to the table
+func (cwp *ConsumerWorkbenchPage) fetch() {
+	if cwp.sub == nil {
+		cwp.notify("Not bound to a pull consumer", 3*time.Second, "error")
+		return
+	}
+	if cwp.fetching {
+		cwp.notify("A fetch is already running", 2*time.Second, "warn")
+		return
+	}
+	batch, opts, err := cwp.fetchOptions()
+	if err != nil {
+		cwp.notify("Invalid fetch options: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	cwp.fetching = true
+	sub := cwp.sub
+	cwp.notify(fmt.Sprintf("Fetching up to %d messages...", batch), 10*time.Second, "info")
+	go func() {
+		msgs, err := sub.Fetch(batch, opts...)
+		cwp.app.QueueUpdateDraw(func() {
+			cwp.fetching = false
+			if sub != cwp.sub {
+				return
+			}
+			if err != nil && !errors.Is(err, nats.ErrTimeout) {
+				cwp.notify("Fetch failed: "+err.Error(), 3*time.Second, "error")
+			}
+			for _, m := range msgs {
+				meta, err := m.Metadata()
+				if err != nil {
+					logger.Error("Failed to read message metadata: %v", err)
+					continue
+				}
+				cwp.msgs = append(cwp.msgs, &workbenchMsg{msg: m, meta: meta, status: "pending"})
+			}
+			if len(msgs) == 0 {
+				cwp.notify("No messages available", 3*time.Second, "info")
+			} else {
+				cwp.notify(fmt.Sprintf("Fetched %d messages", len(msgs)), 3*time.Second, "info")
+			}
+			cwp.renderTable()
+		})
+	}()
+}
+
+// respond sends an acknowledgement for the message under the cursor in the
+// background and moves on to the next one. Only pending or in progress
+// messages can be answered.
+func (cwp *ConsumerWorkbenchPage) respond(status string, ack func(m *nats.Msg) error) {
+	wm := cwp.currentMsg()
+	if wm == nil {
+		cwp.notify("No message selected", 2*time.Second, "error")
+		return
+	}
+	if wm.status == "sending" {
+		cwp.notify("An acknowledgement for this message is still being sent", 3*time.Second, "warn")
+		return
+	}
+	if wm.status != "pending" && wm.status != "in progress" {
+		cwp.notify("Message was already "+wm.status, 3*time.Second, "warn")
+		return
+	}
+
+	previous := wm.status
+	wm.status = "sending"
+	row, _ := cwp.msgTable.GetSelection()
+	cwp.renderTable()
+	if status != "in progress" && row < len(cwp.msgs) {
+		cwp.msgTable.Select(row+1, 0)
+	}
+	go func() {
+		err := ack(wm.msg)
+		cwp.app.QueueUpdateDraw(func() {
+			if err != nil {
+				wm.status = previous
+				cwp.notify("Failed to acknowledge message: "+err.Error(), 3*time.Second, "error")
+			} else {
+				wm.status = status
+			}
+			cwp.renderTable()
+		})
+	}()
+}
+
+// clearHandled removes messages that were acked, nak'd or terminated
+func (cwp *ConsumerWorkbenchPage) clearHandled() {
+	kept := cwp.msgs[:0]
+	for _, wm := range cwp.msgs {
+		if wm.status == "pending" || wm.status == "in progress" || wm.status == "sending" {
+			kept = append(kept, wm)
+		}
+	}
+	cwp.msgs = kept
+	cwp.renderTable()
+}
+
+func workbenchStatusColor(status string) tcell.Color {
+	switch {
+	case status == "acked":
+		return tcell.ColorGreen
+	case status == "termed":
+		return tcell.ColorRed
+	case strings.HasPrefix(status, "nak'd"):
+		return tcell.ColorOrange
+	case status == "in progress":
+		return tcell.ColorYellow
+	}
+	return tcell.ColorWhite
+}
+
+func (cwp *ConsumerWorkbenchPage) renderTable() {
+	cwp.msgTable.Clear()
+	for c, title := range []string{"Stream Seq", "Consumer Seq", "Delivered", "Time", "Subject", "Size", "Status"} {
+		cwp.msgTable.SetCell(0, c, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+	pending := 0
+	for r, wm := range cwp.msgs {
+		if wm.status == "pending" || wm.status == "in progress" || wm.status == "sending" {
+			pending++
+		}
+		delivered := tview.NewTableCell(strconv.FormatUint(wm.meta.NumDelivered, 10)).SetAlign(tview.AlignRight)
+		if wm.meta.NumDelivered > 1 {
+			delivered.SetTextColor(tcell.ColorOrange)
+		}
+		cwp.msgTable.SetCell(r+1, 0, tview.NewTableCell(strconv.FormatUint(wm.meta.Sequence.Stream, 10)).SetAlign(tview.AlignRight))
+		cwp.msgTable.SetCell(r+1, 1, tview.NewTableCell(strconv.FormatUint(wm.meta.Sequence.Consumer, 10)).SetAlign(tview.AlignRight))
+		cwp.msgTable.SetCell(r+1, 2, delivered)
+		cwp.msgTable.SetCell(r+1, 3, tview.NewTableCell(wm.meta.Timestamp.Local().Format("2006-01-02 15:04:05")))
+		cwp.msgTable.SetCell(r+1, 4, tview.NewTableCell(tview.Escape(wm.msg.Subject)).SetMaxWidth(40).SetExpansion(1))
+		cwp.msgTable.SetCell(r+1, 5, tview.NewTableCell(humanBytes(uint64(len(wm.msg.Data)))).SetAlign(tview.AlignRight))
+		cwp.msgTable.SetCell(r+1, 6, tview.NewTableCell(wm.status).SetTextColor(workbenchStatusColor(wm.status)))
+	}
+
+	title := fmt.Sprintf("Workbench %s > %s", cwp.streamName, cwp.consumerName)
+	if len(cwp.msgs) > 0 {
+		title += fmt.Sprintf(" (%d fetched, %d unacked)", len(cwp.msgs), pending)
+	}
+	cwp.tableBox.SetTitle(title)
+
+	if len(cwp.msgs) > 0 {
+		row, _ := cwp.msgTable.GetSelection()
+		if row < 1 || row > len(cwp.msgs) {
+			row = 1
+		}
+		cwp.msgTable.Select(row, 0)
+	}
+	cwp.showDetail()
+}
+
+func (cwp *ConsumerWorkbenchPage) currentMsg() *workbenchMsg {
+	row, _ := cwp.msgTable.GetSelection()
+	if row < 1 || row > len(cwp.msgs) {
+		return nil
+	}
+	return cwp.msgs[row-1]
+}
+
+// showDetail renders the delivery metadata, headers and payload of the
+// message under the cursor
+func (cwp *ConsumerWorkbenchPage) showDetail() {
+	wm := cwp.currentMsg()
+	if wm == nil {
+		cwp.detailView.SetText("")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[yellow]Stream Seq:[-]   %d\n", wm.meta.Sequence.Stream))
+	sb.WriteString(fmt.Sprintf("[yellow]Consumer Seq:[-] %d\n", wm.meta.Sequence.Consumer))
+	sb.WriteString(fmt.Sprintf("[yellow]Delivered:[-]    %d times\n", wm.meta.NumDelivered))
+	sb.WriteString(fmt.Sprintf("[yellow]Pending:[-]      %d after this one\n", wm.meta.NumPending))
+	sb.WriteString("[yellow]Subject:[-]      " + tview.Escape(wm.msg.Subject) + "\n")
+	sb.WriteString("[yellow]Time:[-]         " + wm.meta.Timestamp.Local().Format(time.RFC3339Nano) + "\n")
+	sb.WriteString("[yellow]Status:[-]       " + wm.status + "\n")
+	if len(wm.msg.Header) > 0 {
+		keys := make([]string, 0, len(wm.msg.Header))
+		for k := range wm.msg.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("[yellow]Headers:[-]\n")
+		for _, k := range keys {
+			for _, v := range wm.msg.Header[k] {
+				sb.WriteString("  " + tview.Escape(k+": "+v) + "\n")
+			}
+		}
+	}
+	sb.WriteString("\n" + tview.Escape(string(wm.msg.Data)))
+	cwp.detailView.SetText(sb.String())
+	cwp.detailView.ScrollToBeginning()
+}
+
+func (cwp *ConsumerWorkbenchPage) goBack() {
+	cwp.close()
+	cwp.msgs = nil
+	pages.SwitchToPage("consumerListPage")
+	_, b := pages.GetFrontPage()
+	b.(*ConsumerListPage).redraw(&cwp.Data.CurrCtx)
+	cwp.app.SetFocus(b)
+}
+
+func (cwp *ConsumerWorkbenchPage) notify(message string, duration time.Duration, logLevel string) {
+	cwp.footerTxt.SetText(message)
+	cwp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		cwp.footerTxt.SetText("")
+		cwp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
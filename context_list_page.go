@@ -201,6 +201,15 @@ func (cp *ContextPage) setupInputCapture() {
 				b.(*StreamListPage).redraw(&data.CurrCtx)
 
 			}()
+		} else if event.Rune() == 'c' || event.Rune() == 'C' {
+			if len(cp.Data.Contexts) < 2 {
+				cp.notify("Two contexts are needed for a comparison", 5*time.Second, "error")
+				return event
+			}
+			idx := cp.ctxListView.GetCurrentItem()
+			pages.SwitchToPage("comparePage")
+			_, b := pages.GetFrontPage()
+			b.(*ComparePage).open(cp.Data.Contexts[idx].Name)
 		} else if event.Rune() == 'n' || event.Rune() == 'N' {
 			idx := cp.ctxListView.GetCurrentItem()
 			if len(cp.Data.Contexts) == 0 {
@@ -296,8 +305,15 @@ func createContexListHeaderRow() *tview.Flex {
 	headerRow2.AddItem(createTextView("[j] Jetstream", tcell.ColorWhite), 0, 1, false)
 	headerRow2.AddItem(createTextView("[Del] Delete", tcell.ColorWhite), 0, 1, false)
 
+	headerRow3 := tview.NewFlex()
+	headerRow3.SetDirection(tview.FlexRow)
+	headerRow3.SetBorder(false)
+
+	headerRow3.AddItem(createTextView("[c] Compare", tcell.ColorWhite), 0, 1, false)
+
 	headerRow.AddItem(headerRow1, 0, 1, false)
 	headerRow.AddItem(headerRow2, 0, 1, false)
+	headerRow.AddItem(headerRow3, 0, 1, false)
 	headerRow.SetTitle("NATS-DASH")
 
 	return headerRow
@@ -38,12 +38,12 @@ type NatsCliContext struct {
 	UserJWT              string `json:"user_jwt"`
 }
 type Context struct {
-	Name        string
-	CtxData     NatsCliContext
-	LogFilePath string             `json:"-"`
-	LogFile     *os.File           `json:"-"`
-	Conn        *nats.Conn         `json:"-"`
-	CoreNatsSub *nats.Subscription `json:"-"`
+	Name         string
+	CtxData      NatsCliContext
+	LogFilePath  string               `json:"-"`
+	LogFile      *os.File             `json:"-"`
+	Conn         *nats.Conn           `json:"-"`
+	CoreNatsSubs []*nats.Subscription `json:"-"`
 }
 
 func GetConfigDir() (string, error) {
@@ -0,0 +1,157 @@
+package ds
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/solidpulse/natsdash/logger"
+)
+
+// maximum number of entries kept in a context's publish history
+const MaxPublishHistory = 200
+
+type PublishTemplate struct {
+	Name    string            `json:"name"`
+	Subject string            `json:"subject"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+type PublishHistoryEntry struct {
+	Subject  string            `json:"subject"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body"`
+	Template bool              `json:"template,omitempty"` // sent with template expansion
+	Time     time.Time         `json:"time"`
+}
+
+// GetContextDataDir returns the directory holding per-context files. It lives
+// next to the context file, e.g. <config>/nats/context/<name>/
+func GetContextDataDir(ctxName string) (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configDir, "context", ctxName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// loadContextFile decodes a JSON file from the context data dir into v. A
+// missing file leaves v untouched.
+func loadContextFile(ctxName, fileName string, v interface{}) error {
+	dir, err := GetContextDataDir(ctxName)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filepath.Join(dir, fileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+func saveContextFile(ctxName, fileName string, v interface{}) error {
+	dir, err := GetContextDataDir(ctxName)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(dir, fileName)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		logger.Error("Failed to write file %s: %v", filePath, err)
+		return err
+	}
+	return nil
+}
+
+func LoadPublishTemplates(ctxName string) ([]PublishTemplate, error) {
+	templates := make([]PublishTemplate, 0)
+	err := loadContextFile(ctxName, "templates.json", &templates)
+	return templates, err
+}
+
+// SavePublishTemplate adds the template, replacing any existing one with the same name
+func SavePublishTemplate(ctxName string, tmpl PublishTemplate) error {
+	templates, err := LoadPublishTemplates(ctxName)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range templates {
+		if templates[i].Name == tmpl.Name {
+			templates[i] = tmpl
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		templates = append(templates, tmpl)
+	}
+	return saveContextFile(ctxName, "templates.json", templates)
+}
+
+func DeletePublishTemplate(ctxName string, name string) error {
+	templates, err := LoadPublishTemplates(ctxName)
+	if err != nil {
+		return err
+	}
+	kept := make([]PublishTemplate, 0, len(templates))
+	for _, t := range templates {
+		if t.Name != name {
+			kept = append(kept, t)
+		}
+	}
+	return saveContextFile(ctxName, "templates.json", kept)
+}
+
+// LoadPublishHistory returns the history with the most recent send last
+func LoadPublishHistory(ctxName string) ([]PublishHistoryEntry, error) {
+	history := make([]PublishHistoryEntry, 0)
+	err := loadContextFile(ctxName, "history.json", &history)
+	return history, err
+}
+
+func AppendPublishHistory(ctxName string, entry PublishHistoryEntry) ([]PublishHistoryEntry, error) {
+	history, err := LoadPublishHistory(ctxName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-sending the previous message only bumps its timestamp
+	if n := len(history); n > 0 && history[n-1].Subject == entry.Subject && history[n-1].Body == entry.Body {
+		history[n-1] = entry
+	} else {
+		history = append(history, entry)
+	}
+	if len(history) > MaxPublishHistory {
+		history = history[len(history)-MaxPublishHistory:]
+	}
+	return history, saveContextFile(ctxName, "history.json", history)
+}
+
+// SchemaMapping binds a subject pattern (NATS wildcards) to a JSON Schema file
+type SchemaMapping struct {
+	Subject string `json:"subject" yaml:"subject"`
+	Schema  string `json:"schema" yaml:"schema"`
+}
+
+func LoadSchemaMappings(ctxName string) ([]SchemaMapping, error) {
+	mappings := make([]SchemaMapping, 0)
+	err := loadContextFile(ctxName, "schemas.json", &mappings)
+	return mappings, err
+}
+
+func SaveSchemaMappings(ctxName string, mappings []SchemaMapping) error {
+	return saveContextFile(ctxName, "schemas.json", mappings)
+}
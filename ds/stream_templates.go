@@ -0,0 +1,81 @@
+package ds
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/solidpulse/natsdash/logger"
+)
+
+// StreamTemplate is a user defined starting point for new streams. Templates
+// aren't bound to a context so they can be reused everywhere.
+type StreamTemplate struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Config      map[string]interface{} `json:"config"`
+}
+
+func streamTemplatesFile() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "natsdash", "stream_templates.json"), nil
+}
+
+func LoadStreamTemplates() ([]StreamTemplate, error) {
+	templates := make([]StreamTemplate, 0)
+	filePath, err := streamTemplatesFile()
+	if err != nil {
+		return templates, err
+	}
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return templates, err
+	}
+	err = json.Unmarshal(content, &templates)
+	return templates, err
+}
+
+// SaveStreamTemplate adds the template, replacing any existing one with the same name
+func SaveStreamTemplate(tmpl StreamTemplate) error {
+	templates, err := LoadStreamTemplates()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range templates {
+		if templates[i].Name == tmpl.Name {
+			templates[i] = tmpl
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		templates = append(templates, tmpl)
+	}
+	return saveStreamTemplates(templates)
+}
+
+func saveStreamTemplates(templates []StreamTemplate) error {
+	filePath, err := streamTemplatesFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		logger.Error("Failed to write file %s: %v", filePath, err)
+		return err
+	}
+	return nil
+}
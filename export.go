@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/natsutil"
+	"gopkg.in/yaml.v2"
+)
+
+// exportManifest reads the JetStream topology of the connection into a
+// manifest apply accepts. Only configuration is exported, empty values and
+// server managed metadata are left out, and everything is sorted by name so
+// the output is stable.
+func exportManifest(nc *nats.Conn) (*jsManifest, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for name := range js.StreamNames() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m := &jsManifest{}
+	for _, name := range names {
+		config, err := natsutil.StreamConfigRaw(nc, name)
+		if natsutil.IsNotFound(err) {
+			continue // deleted while exporting
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stream %s: %v", name, err)
+		}
+		stripServerMetadata(config)
+
+		switch {
+		case strings.HasPrefix(name, kvBucketKind.prefix):
+			m.KeyValue = append(m.KeyValue, exportBucket(kvBucketKind, name, config))
+		case strings.HasPrefix(name, objectBucketKind.prefix):
+			m.ObjectStores = append(m.ObjectStores, exportBucket(objectBucketKind, name, config))
+		default:
+			nanosToDurationStrings(config, streamDurationFields)
+			if limits, ok := config["consumer_limits"].(map[string]interface{}); ok {
+				nanosToDurationStrings(limits, consumerLimitsDurationFields)
+			}
+			m.Streams = append(m.Streams, cleanExportValues(config))
+
+			consumers, err := exportConsumers(js, name)
+			if err != nil {
+				return nil, fmt.Errorf("consumers of %s: %v", name, err)
+			}
+			m.Consumers = append(m.Consumers, consumers...)
+		}
+	}
+	return m, nil
+}
+
+// exportConsumers returns the durable consumers of the stream. Ephemeral
+// consumers belong to running clients and aren't part of the setup.
+func exportConsumers(js nats.JetStreamContext, stream string) ([]map[string]interface{}, error) {
+	infos := make([]*nats.ConsumerInfo, 0)
+	for info := range js.Consumers(stream) {
+		if info.Config.Durable != "" {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	consumers := make([]map[string]interface{}, 0, len(infos))
+	for _, info := range infos {
+		var config map[string]interface{}
+		b, err := json.Marshal(info.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return nil, err
+		}
+		if config["name"] == config["durable_name"] {
+			delete(config, "name")
+		}
+		stripServerMetadata(config)
+		consumerEditorValues(config)
+		config["stream"] = stream
+		consumers = append(consumers, cleanExportValues(config))
+	}
+	return consumers, nil
+}
+
+// exportBucket expresses the backing stream config in bucket terms
+func exportBucket(kind bucketKind, streamName string, config map[string]interface{}) map[string]interface{} {
+	values := map[string]interface{}{"bucket": strings.TrimPrefix(streamName, kind.prefix)}
+	for key, streamKey := range kind.streamKeys {
+		v, ok := config[streamKey]
+		if !ok {
+			continue
+		}
+		if key == "compression" {
+			v = v == "s2"
+		}
+		values[key] = v
+	}
+	nanosToDurationStrings(values, kind.durations)
+	return cleanExportValues(values)
+}
+
+// stripServerMetadata removes the metadata keys the server maintains itself
+func stripServerMetadata(config map[string]interface{}) {
+	metadata, ok := config["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k := range metadata {
+		if strings.HasPrefix(k, "_nats.") {
+			delete(metadata, k)
+		}
+	}
+}
+
+// cleanExportValues drops empty values and turns whole numbers back into
+// integers so they aren't written in exponent notation
+func cleanExportValues(config map[string]interface{}) map[string]interface{} {
+	cleaned, _ := integralNumbers(dropEmptyValues(config)).(map[string]interface{})
+	if cleaned == nil {
+		cleaned = make(map[string]interface{})
+	}
+	return cleaned
+}
+
+func integralNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			t[k] = integralNumbers(child)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = integralNumbers(child)
+		}
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return int64(t)
+		}
+	}
+	return v
+}
+
+// marshalManifest writes the manifest as "yaml" or "json"
+func marshalManifest(m *jsManifest, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case "yaml", "":
+		return yaml.Marshal(m)
+	default:
+		return nil, fmt.Errorf("unknown format %q, use yaml or json", format)
+	}
+}
+
+// manifestFormat picks the format from the file extension
+func manifestFormat(file string) string {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// writeManifestFile exports the topology of the connection to file
+func writeManifestFile(nc *nats.Conn, file string) (*jsManifest, error) {
+	m, err := exportManifest(nc)
+	if err != nil {
+		return nil, err
+	}
+	b, err := marshalManifest(m, manifestFormat(file))
+	if err != nil {
+		return nil, err
+	}
+	if dir := filepath.Dir(file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return m, os.WriteFile(file, b, 0644)
+}
+
+// showExportDialog asks where to write the manifest of the current context
+func (sp *StreamListPage) showExportDialog() {
+	initial := sp.Data.CurrCtx.Name + "-jetstream.yaml"
+	showPromptModal("Export Manifest", "File (.yaml or .json): ", initial, func(text string) {
+		file := strings.TrimSpace(text)
+		if file == "" {
+			sp.notify("A file name is required", 3*time.Second, "error")
+			return
+		}
+		if _, err := os.Stat(file); err == nil {
+			showConfirmModal("Overwrite manifest", tview.Escape(file)+" exists, overwrite it?", func() {
+				go sp.runExport(file)
+			})
+			return
+		} else if !errors.Is(err, os.ErrNotExist) {
+			sp.notify("Can't write "+file+": "+err.Error(), 3*time.Second, "error")
+			return
+		}
+		go sp.runExport(file)
+	})
+}
+
+func (sp *StreamListPage) runExport(file string) {
+	sp.progressNotify("Exporting "+sp.Data.CurrCtx.Name+"...", "info")
+	m, err := writeManifestFile(sp.Data.CurrCtx.Conn, file)
+	if err != nil {
+		sp.progressNotify("Export failed: "+err.Error(), "error")
+		return
+	}
+	sp.progressNotify(fmt.Sprintf("Exported %d streams, %d consumers, %d KV and %d object store buckets to %s",
+		len(m.Streams), len(m.Consumers), len(m.KeyValue), len(m.ObjectStores), file), "info")
+}
+
+// runExportCommand implements `natsdash export --context NAME [-o FILE] [--format yaml|json]`.
+// It returns the process exit code.
+func runExportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	ctxName := flags.String("context", "", "saved context to export")
+	file := flags.String("o", "", "output file, standard output when empty")
+	format := flags.String("format", "", "yaml or json, taken from the file extension by default")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *ctxName == "" {
+		fmt.Fprintln(os.Stderr, "usage: natsdash export --context NAME [-o FILE] [--format yaml|json]")
+		return 2
+	}
+	if *format == "" && *file != "" {
+		*format = manifestFormat(*file)
+	}
+
+	if err := loadContexts(); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load contexts:", err)
+		return 1
+	}
+	conn, closeConn, err := contextConn(*ctxName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to %s: %v\n", *ctxName, err)
+		return 1
+	}
+	defer closeConn()
+
+	m, err := exportManifest(conn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Export failed:", err)
+		return 1
+	}
+	b, err := marshalManifest(m, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *file == "" {
+		os.Stdout.Write(b)
+		return 0
+	}
+	if err := os.WriteFile(*file, b, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -2,11 +2,13 @@ module github.com/solidpulse/natsdash
 
 go 1.23.1
 
-require github.com/rivo/tview v0.0.0-20240921122403-a64fc48d7654
+require (
+	github.com/nats-io/nats.go v1.37.0
+	github.com/rivo/tview v0.0.0-20240921122403-a64fc48d7654
+)
 
 require (
 	github.com/klauspost/compress v1.17.2 // indirect
-	github.com/nats-io/nats.go v1.37.0 // indirect
 	github.com/nats-io/nkeys v0.4.7 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
 	golang.org/x/crypto v0.18.0 // indirect
@@ -15,7 +17,7 @@ require (
 
 require (
 	github.com/gdamore/encoding v1.0.0 // indirect
-	github.com/gdamore/tcell/v2 v2.7.1 // indirect
+	github.com/gdamore/tcell/v2 v2.7.1
 	github.com/google/uuid v1.6.0
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
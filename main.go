@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
 	"github.com/solidpulse/natsdash/logger"
@@ -10,9 +12,25 @@ var app *tview.Application
 var pages *tview.Pages
 var data *ds.Data
 
+// commands run without the UI, e.g. `natsdash apply -f manifest.yaml --context NAME`
+var commands = map[string]func(args []string) int{
+	"apply":  runApplyCommand,
+	"export": runExportCommand,
+}
+
 func main() {
 	logger.Init()
 	defer logger.CloseLogger()
+
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			data = &ds.Data{}
+			code := command(os.Args[2:])
+			logger.CloseLogger()
+			os.Exit(code)
+		}
+	}
+
 	app = tview.NewApplication()
 	pages = tview.NewPages()
 
@@ -25,18 +43,38 @@ func main() {
 	streamListPage := NewStreamListPage(app, data)
 	StreamAddPage := NewStreamAddPage(app, data)
 	StreamInfoPage := NewStreamInfoPage(app, data)
+	streamSourcesPage := NewStreamSourcesPage(app, data)
+	streamBrowserPage := NewStreamBrowserPage(app, data)
+	applyPage := NewApplyPage(app, data)
+	subjectExplorerPage := NewSubjectExplorerPage(app, data)
+	subjectDiscoveryPage := NewSubjectDiscoveryPage(app, data)
+	comparePage := NewComparePage(app, data)
 	ConsumerListPage := NewConsumerListPage(app, data)
 	ConsumerAddPage := NewConsumerAddPage(app, data)
 	ConsumerInfoPage := NewConsumerInfoPage(app, data)
+	consumerWorkbenchPage := NewConsumerWorkbenchPage(app, data)
 	StreamViewPage := NewStreamViewPage(app, data)
+	replayPage := NewReplayPage(app, data)
+	benchPage := NewBenchPage(app, data)
+	schemaMapPage := NewSchemaMapPage(app, data)
 
 	pages.AddPage("natsPage", natsPage, true, false)
+	pages.AddPage("replayPage", replayPage, true, false)
+	pages.AddPage("benchPage", benchPage, true, false)
+	pages.AddPage("schemaMapPage", schemaMapPage, true, false)
 	pages.AddPage("streamListPage", streamListPage, true, false)
 	pages.AddPage("consumerListPage", ConsumerListPage, true, false)
 	pages.AddPage("consumerAddPage", ConsumerAddPage, true, false)
 	pages.AddPage("consumerInfoPage", ConsumerInfoPage, true, false)
+	pages.AddPage("consumerWorkbenchPage", consumerWorkbenchPage, true, false)
 	pages.AddPage("streamAddPage", StreamAddPage, true, false)
 	pages.AddPage("streamInfoPage", StreamInfoPage, true, false)
+	pages.AddPage("streamSourcesPage", streamSourcesPage, true, false)
+	pages.AddPage("streamBrowserPage", streamBrowserPage, true, false)
+	pages.AddPage("applyPage", applyPage, true, false)
+	pages.AddPage("subjectExplorerPage", subjectExplorerPage, true, false)
+	pages.AddPage("subjectDiscoveryPage", subjectDiscoveryPage, true, false)
+	pages.AddPage("comparePage", comparePage, true, false)
 	pages.AddPage("streamViewPage", StreamViewPage, true, false)
 	pages.AddPage("consumerInfoPage", ConsumerInfoPage, true, false)
 	pages.AddPage("contextFormPage", contextFormPage, true, false)
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/nats-io/nats.go"
@@ -19,7 +23,10 @@ type NatsPage struct {
 	subjectFilter *tview.InputField
 	logView       *tview.TextView
 	subjectName   *tview.InputField
+	headersInput  *tview.InputField
 	txtArea       *tview.TextArea
+	composer      *publishComposer
+	filePayload   []byte        // payload loaded from a file, sent byte for byte
 	tailingDone   chan struct{} // Add this line
 	tailingMutex  sync.Mutex    // Add this line
 }
@@ -40,7 +47,7 @@ func NewNatsPage(app *tview.Application, data *ds.Data) *NatsPage {
 func (cfp *NatsPage) setupUI() {
 	// Header setup
 	headerRow := createNatsPageHeaderRow()
-	cfp.AddItem(headerRow, 2, 6, false)
+	cfp.AddItem(headerRow, 4, 6, false)
 
 	// Initialize fields
 	cfp.subjectFilter = tview.NewInputField()
@@ -84,21 +91,46 @@ func (cfp *NatsPage) setupUI() {
 	cfp.subjectName.SetLabel("Target Subject: ")
 	cfp.subjectName.SetBorder(true)
 	cfp.subjectName.SetDoneFunc(func(key tcell.Key) {
-		cfp.app.SetFocus(cfp.txtArea)
+		cfp.app.SetFocus(cfp.headersInput)
 	})
 	cfp.subjectName.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyTab {
+			cfp.app.SetFocus(cfp.headersInput)
+			return nil
+		}
+		return event
+	})
+
+	cfp.headersInput = tview.NewInputField()
+	cfp.headersInput.SetLabel("Headers: ")
+	cfp.headersInput.SetPlaceholder("Key=value; Other=value")
+	cfp.headersInput.SetBorder(true)
+	cfp.headersInput.SetDoneFunc(func(key tcell.Key) {
+		cfp.app.SetFocus(cfp.txtArea)
+	})
+	cfp.headersInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyTab {
 			cfp.app.SetFocus(cfp.txtArea)
 			return nil
 		}
 		return event
 	})
-	cfp.AddItem(cfp.subjectName, 3, 6, false)
+
+	targetRow := tview.NewFlex().SetDirection(tview.FlexColumn)
+	targetRow.AddItem(cfp.subjectName, 0, 1, false)
+	targetRow.AddItem(cfp.headersInput, 0, 1, false)
+	cfp.AddItem(targetRow, 3, 6, false)
 
 	cfp.txtArea = tview.NewTextArea()
 	cfp.txtArea.SetPlaceholder("Message...")
 	cfp.txtArea.SetBorder(true)
 	cfp.txtArea.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if cfp.filePayload != nil && (event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 || event.Key() == tcell.KeyDelete) {
+			// The preview of a file payload is read-only, deleting drops the file
+			cfp.clearFilePayload()
+			cfp.txtArea.SetText("", false)
+			return nil
+		}
 		if event.Key() == tcell.KeyTab {
 			cfp.app.SetFocus(cfp.subjectFilter)
 			return nil
@@ -110,12 +142,17 @@ func (cfp *NatsPage) setupUI() {
 	})
 	cfp.AddItem(cfp.txtArea, 0, 8, false)
 	cfp.SetBorderPadding(0, 0, 1, 1)
+
+	cfp.composer = newPublishComposer(cfp.subjectName, cfp.headersInput, cfp.txtArea, cfp.log)
+	// Templates and history replace a loaded file
+	cfp.composer.onFill = cfp.clearFilePayload
 }
 
 func (cfp *NatsPage) redraw(ctx *ds.Context) {
 	// Update log view title with the current context's log file path
 	cfp.logView.SetTitle(ctx.LogFilePath)
 	cfp.resetTailFile(ctx.LogFilePath)
+	cfp.composer.reset(ctx.Name)
 	cfp.app.SetFocus(cfp.subjectFilter)
 	go cfp.app.Draw()
 }
@@ -126,17 +163,48 @@ func (cfp *NatsPage) setupInputCapture() {
 		case event.Key() == tcell.KeyEsc:
 			cfp.goBackToContextPage()
 			return nil
+		case event.Key() == tcell.KeyCtrlR:
+			pages.SwitchToPage("replayPage")
+			_, b := pages.GetFrontPage()
+			b.(*ReplayPage).redraw(&cfp.Data.CurrCtx)
+			return nil
+		case event.Key() == tcell.KeyCtrlP:
+			pages.SwitchToPage("benchPage")
+			_, b := pages.GetFrontPage()
+			benchPage := b.(*BenchPage)
+			benchPage.returnPage = "natsPage"
+			benchPage.redraw(&cfp.Data.CurrCtx)
+			return nil
+		case event.Key() == tcell.KeyCtrlO:
+			showPromptModal("Load Payload", "File: ", "", cfp.loadPayloadFile)
+			return nil
+		case event.Key() == tcell.KeyCtrlN:
+			showPromptModal("Publish Batch File", "File: ", "", func(filePath string) {
+				if filePath == "" {
+					return
+				}
+				items := []pickerItem{
+					{Text: "Publish documents as-is", Secondary: "every JSON document or line is one payload"},
+					{Text: "Documents are message envelopes", Secondary: `JSON objects with "subject", "data" and "headers" keys`},
+				}
+				showPickerModal("Batch File Format", items, func(idx int) {
+					cfp.publishBatchFile(filePath, idx == 1)
+				})
+			})
+			return nil
+		case event.Key() == tcell.KeyRune && event.Rune() == 'd' && event.Modifiers()&tcell.ModAlt != 0:
+			pages.SwitchToPage("subjectDiscoveryPage")
+			_, b := pages.GetFrontPage()
+			b.(*SubjectDiscoveryPage).redraw(&cfp.Data.CurrCtx)
+			return nil
 		}
-		return event
+		return cfp.composer.handleKey(event, cfp.Data.CurrCtx.Name)
 	})
 }
 
 func (cfp *NatsPage) goBackToContextPage() {
-	// Unsubscribe from NATS subject if there's an active subscription
-	if cfp.Data.CurrCtx.CoreNatsSub != nil {
-		cfp.Data.CurrCtx.CoreNatsSub.Unsubscribe()
-		cfp.Data.CurrCtx.CoreNatsSub = nil
-	}
+	// Unsubscribe from the NATS subjects of the active subscriptions
+	cfp.unsubscribeAll()
 
 	// Clear the filter text
 	cfp.subjectFilter.SetText("")
@@ -166,7 +234,9 @@ func createNatsPageHeaderRow() *tview.Flex {
 	headerRow1.SetDirection(tview.FlexRow)
 	headerRow1.SetBorder(false)
 
-	headerRow1.AddItem(createTextView("[Esc] Back  |  [Tab] Focus Next  | [Alt+Enter] Send ", tcell.ColorWhite), 0, 1, false)
+	headerRow1.AddItem(createTextView("[Esc] Back  |  [Tab] Focus Next  | [Alt+Enter] Send  | [Ctrl+R] Replay  | [Ctrl+P] Benchmark ", tcell.ColorWhite), 0, 1, false)
+	headerRow1.AddItem(createTextView("[Ctrl+O] Load Payload File  | [Ctrl+N] Publish Batch File  | [Alt+D] Discover Subjects", tcell.ColorWhite), 0, 1, false)
+	headerRow1.AddItem(createTextView(publishComposerKeys, tcell.ColorWhite), 0, 1, false)
 
 	headerRow.AddItem(headerRow1, 0, 1, false)
 	headerRow.SetTitle("NATS-DASH")
@@ -226,43 +296,187 @@ func (cfp *NatsPage) resetTailFile(logFilePath string) {
 	}()
 }
 
-func (cfp *NatsPage) subscribeToSubject(subject string) {
+// subscribeToSubject subscribes to the subjects of the filter. Several
+// subjects are separated by commas.
+func (cfp *NatsPage) subscribeToSubject(filter string) {
 	hourMinSec := time.Now().Format("15:04:05.00000")
-	// check if subject is already subscribed
-	if cfp.Data.CurrCtx.CoreNatsSub != nil && cfp.Data.CurrCtx.CoreNatsSub.Subject == subject {
-		cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " DEBUG: Already subscribed to " + subject + "\n")
+	subjects := splitSubjects(filter)
+	// check if the subjects are already subscribed
+	if len(subjects) > 0 && strings.Join(subjects, ", ") == strings.Join(cfp.subscribedSubjects(), ", ") {
+		cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " DEBUG: Already subscribed to " + filter + "\n")
 		return
 	}
 
-	// Unsubscribe from the previous subject if any
-	if cfp.Data.CurrCtx.CoreNatsSub != nil {
-		cfp.Data.CurrCtx.CoreNatsSub.Unsubscribe()
-	}
+	// Unsubscribe from the previous subjects if any
+	cfp.unsubscribeAll()
 
-	// Subscribe to the new subject
-	sub, err := cfp.Data.CurrCtx.Conn.Subscribe(subject, func(msg *nats.Msg) {
-		// Log the incoming message to the log file
-		hourMinSec := time.Now().Format("15:04:05.00000")
-		cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " SUB[" + msg.Subject + "] " + string(msg.Data) + "\n")
-		cfp.logView.ScrollToEnd()
-	})
-	if err != nil {
-		cfp.Data.CurrCtx.LogFile.WriteString(err.Error() + "\n")
-	} else {
+	// Subscribe to the new subjects
+	for _, subject := range subjects {
+		sub, err := cfp.Data.CurrCtx.Conn.Subscribe(subject, func(msg *nats.Msg) {
+			// Log the incoming message to the log file
+			hourMinSec := time.Now().Format("15:04:05.00000")
+			cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " SUB[" + msg.Subject + "] " + string(msg.Data) + "\n")
+			if marker := schemaViolationLine(cfp.Data.CurrCtx.Name, msg); marker != "" {
+				cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " " + marker + "\n")
+			}
+			cfp.logView.ScrollToEnd()
+		})
+		if err != nil {
+			cfp.Data.CurrCtx.LogFile.WriteString(err.Error() + "\n")
+			continue
+		}
 		hourMinSec := time.Now().Format("15:04:05.00000")
 		cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " Subscribed to " + subject + "\n")
+		cfp.Data.CurrCtx.CoreNatsSubs = append(cfp.Data.CurrCtx.CoreNatsSubs, sub)
+	}
+}
+
+func (cfp *NatsPage) subscribedSubjects() []string {
+	subjects := make([]string, 0, len(cfp.Data.CurrCtx.CoreNatsSubs))
+	for _, sub := range cfp.Data.CurrCtx.CoreNatsSubs {
+		subjects = append(subjects, sub.Subject)
+	}
+	return subjects
+}
+
+func (cfp *NatsPage) unsubscribeAll() {
+	for _, sub := range cfp.Data.CurrCtx.CoreNatsSubs {
+		sub.Unsubscribe()
 	}
-	cfp.Data.CurrCtx.CoreNatsSub = sub
+	cfp.Data.CurrCtx.CoreNatsSubs = nil
+}
 
+// pinSubjects adds subjects to the subscription filter and subscribes
+func (cfp *NatsPage) pinSubjects(subjects []string) {
+	pinned := splitSubjects(cfp.subjectFilter.GetText())
+	for _, subject := range subjects {
+		if !containsString(pinned, subject) {
+			pinned = append(pinned, subject)
+		}
+	}
+	cfp.subjectFilter.SetText(strings.Join(pinned, ", "))
+	cfp.subscribeToSubject(cfp.subjectFilter.GetText())
+}
+
+// splitSubjects returns the subjects of a comma separated list
+func splitSubjects(text string) []string {
+	subjects := make([]string, 0)
+	for _, subject := range strings.Split(text, ",") {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects
 }
 
 func (cfp *NatsPage) sendMessage() {
-	// Implement the logic to send the message here
-	// For example, you can get the message from cfp.txtArea and send it via NATS
-	message := cfp.txtArea.GetText()
-	subject := cfp.subjectName.GetText()
-	cfp.Data.CurrCtx.Conn.Publish(subject, []byte(message))
+	var msg *nats.Msg
+	var err error
+	if cfp.filePayload != nil {
+		// File payloads are sent byte for byte
+		msg, err = cfp.composer.buildFileMsg(cfp.filePayload)
+	} else {
+		msg, err = cfp.composer.buildMsg()
+	}
+	if err != nil {
+		cfp.log("ERROR: Invalid template: " + err.Error())
+		return
+	}
+	if !cfp.composer.checkSchema(cfp.Data.CurrCtx.Name, msg) {
+		return
+	}
+	if err := cfp.Data.CurrCtx.Conn.PublishMsg(msg); err != nil {
+		cfp.log("ERROR: Failed to publish: " + err.Error())
+		return
+	}
+	hourMinSec := time.Now().Format("15:04:05.00000")
+	cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " PUB[" + msg.Subject + "] " + string(msg.Data) + "\n")
+	cfp.logView.ScrollToEnd()
+	cfp.composer.recordSend(cfp.Data.CurrCtx.Name)
+}
+
+func (cfp *NatsPage) log(message string) {
 	hourMinSec := time.Now().Format("15:04:05.00000")
-	cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " PUB[" + subject + "] " + message + "\n")
+	cfp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " " + message + "\n")
 	cfp.logView.ScrollToEnd()
 }
+
+// loadPayloadFile loads a file as the message body. The file is sent
+// unmodified, text files are previewed read-only in the editor.
+func (cfp *NatsPage) loadPayloadFile(filePath string) {
+	if filePath == "" {
+		return
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		cfp.log("ERROR: Failed to read payload file: " + err.Error())
+		return
+	}
+
+	cfp.filePayload = content
+	cfp.txtArea.SetDisabled(true)
+	cfp.txtArea.SetTitle(fmt.Sprintf("Message (file payload: %s, %d bytes) - Backspace to clear", filePath, len(content)))
+	if utf8.Valid(content) && !bytes.ContainsRune(content, 0) && len(content) <= 256*1024 {
+		cfp.txtArea.SetText(string(content), false)
+		cfp.log(fmt.Sprintf("INFO: Loaded %d bytes from %s", len(content), filePath))
+		return
+	}
+	cfp.txtArea.SetText("", false)
+	cfp.txtArea.SetPlaceholder(fmt.Sprintf("<binary payload: %s (%d bytes)>", filePath, len(content)))
+	cfp.log(fmt.Sprintf("INFO: Loaded binary payload of %d bytes from %s", len(content), filePath))
+}
+
+func (cfp *NatsPage) clearFilePayload() {
+	cfp.filePayload = nil
+	cfp.txtArea.SetDisabled(false)
+	cfp.txtArea.SetPlaceholder("Message...")
+	cfp.txtArea.SetTitle("")
+}
+
+// publishBatchFile publishes every message of a batch file and reports the
+// result of each one
+func (cfp *NatsPage) publishBatchFile(filePath string, envelopes bool) {
+	msgs, err := loadBatchMessages(filePath, cfp.subjectName.GetText(), envelopes)
+	if err != nil {
+		cfp.log("ERROR: Failed to load batch file: " + err.Error())
+		return
+	}
+	batch := make([]*nats.Msg, 0, len(msgs))
+	for i, m := range msgs {
+		if m.Subject == "" {
+			cfp.log(fmt.Sprintf("ERROR: Message %d has no subject and no target subject is set", i+1))
+			return
+		}
+		msg := nats.NewMsg(m.Subject)
+		msg.Data = m.Data
+		for k, v := range m.Header {
+			msg.Header[k] = v
+		}
+		batch = append(batch, msg)
+	}
+	if !cfp.composer.checkBatchSchema(cfp.Data.CurrCtx.Name, filePath, batch) {
+		return
+	}
+
+	conn := cfp.Data.CurrCtx.Conn
+	logAsync := func(message string) {
+		cfp.app.QueueUpdateDraw(func() { cfp.log(message) })
+	}
+	go func() {
+		started := time.Now()
+		ok, failed := 0, 0
+		for i, msg := range batch {
+			if err := conn.PublishMsg(msg); err != nil {
+				failed++
+				logAsync(fmt.Sprintf("BATCH #%d PUB[%s] FAILED: %v", i+1, msg.Subject, err))
+				continue
+			}
+			ok++
+			logAsync(fmt.Sprintf("BATCH #%d PUB[%s] %d bytes", i+1, msg.Subject, len(msg.Data)))
+		}
+		if err := conn.Flush(); err != nil {
+			logAsync("ERROR: Flush failed: " + err.Error())
+		}
+		logAsync(fmt.Sprintf("INFO: Batch %s finished: %d published, %d failed in %s", filePath, ok, failed, time.Since(started).Round(time.Millisecond)))
+	}()
+}
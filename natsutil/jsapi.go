@@ -0,0 +1,220 @@
+package natsutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// The helpers in this file talk to the JetStream API directly with raw JSON
+// so that configuration fields unknown to nats.go survive a round trip.
+
+const (
+	apiPrefix  = "$JS.API."
+	apiTimeout = 5 * time.Second
+)
+
+type APIError struct {
+	Code        int    `json:"code"`
+	ErrorCode   int    `json:"err_code"`
+	Description string `json:"description"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (code %d, err_code %d)", e.Description, e.Code, e.ErrorCode)
+}
+
+// JSRequest sends req (marshalled to JSON unless it is already []byte) to a
+// JetStream API subject such as "STREAM.INFO.ORDERS" and decodes the reply
+func JSRequest(nc *nats.Conn, apiSubject string, req interface{}, resp interface{}) error {
+	var body []byte
+	switch r := req.(type) {
+	case nil:
+	case []byte:
+		body = r
+	default:
+		var err error
+		body, err = json.Marshal(req)
+		if err != nil {
+			return err
+		}
+	}
+
+	msg, err := nc.Request(apiPrefix+apiSubject, body, apiTimeout)
+	if err != nil {
+		return err
+	}
+
+	var apiResp struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(msg.Data, &apiResp); err != nil {
+		return err
+	}
+	if apiResp.Error != nil {
+		return apiResp.Error
+	}
+	if resp != nil {
+		return json.Unmarshal(msg.Data, resp)
+	}
+	return nil
+}
+
+// StreamInfoRaw returns the stream info response as a generic map
+func StreamInfoRaw(nc *nats.Conn, stream string) (map[string]interface{}, error) {
+	var info map[string]interface{}
+	err := JSRequest(nc, "STREAM.INFO."+stream, nil, &info)
+	return info, err
+}
+
+// StreamConfigRaw returns the stream configuration exactly as the server reports it
+func StreamConfigRaw(nc *nats.Conn, stream string) (map[string]interface{}, error) {
+	info, err := StreamInfoRaw(nc, stream)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := info["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("stream info for %s has no config", stream)
+	}
+	return config, nil
+}
+
+// CreateStreamRaw creates a stream from a generic configuration map
+func CreateStreamRaw(nc *nats.Conn, config map[string]interface{}) error {
+	name, _ := config["name"].(string)
+	if name == "" {
+		return fmt.Errorf("stream name is required")
+	}
+	return JSRequest(nc, "STREAM.CREATE."+name, config, nil)
+}
+
+// UpdateStreamRaw updates a stream from a generic configuration map
+func UpdateStreamRaw(nc *nats.Conn, config map[string]interface{}) error {
+	name, _ := config["name"].(string)
+	if name == "" {
+		return fmt.Errorf("stream name is required")
+	}
+	return JSRequest(nc, "STREAM.UPDATE."+name, config, nil)
+}
+
+// ConsumerInfoRaw returns the consumer info response as a generic map
+func ConsumerInfoRaw(nc *nats.Conn, stream string, consumer string) (map[string]interface{}, error) {
+	var info map[string]interface{}
+	err := JSRequest(nc, "CONSUMER.INFO."+stream+"."+consumer, nil, &info)
+	return info, err
+}
+
+// ListStreams returns the info of every stream. Unlike the nats.go listers
+// it reports errors instead of ending the list early.
+func ListStreams(nc *nats.Conn) ([]*nats.StreamInfo, error) {
+	streams := make([]*nats.StreamInfo, 0)
+	for {
+		var page struct {
+			Total   int                `json:"total"`
+			Streams []*nats.StreamInfo `json:"streams"`
+		}
+		req := map[string]interface{}{"offset": len(streams)}
+		if err := JSRequest(nc, "STREAM.LIST", req, &page); err != nil {
+			return nil, err
+		}
+		streams = append(streams, page.Streams...)
+		if len(page.Streams) == 0 || len(streams) >= page.Total {
+			return streams, nil
+		}
+	}
+}
+
+// ListConsumers returns the info of every consumer of the stream. Unlike the
+// nats.go listers it reports errors instead of ending the list early.
+func ListConsumers(nc *nats.Conn, stream string) ([]*nats.ConsumerInfo, error) {
+	consumers := make([]*nats.ConsumerInfo, 0)
+	for {
+		var page struct {
+			Total     int                  `json:"total"`
+			Consumers []*nats.ConsumerInfo `json:"consumers"`
+		}
+		req := map[string]interface{}{"offset": len(consumers)}
+		if err := JSRequest(nc, "CONSUMER.LIST."+stream, req, &page); err != nil {
+			return nil, err
+		}
+		consumers = append(consumers, page.Consumers...)
+		if len(page.Consumers) == 0 || len(consumers) >= page.Total {
+			return consumers, nil
+		}
+	}
+}
+
+// StreamsWithSubject returns the names of the streams whose subjects overlap
+// the given subject
+func StreamsWithSubject(nc *nats.Conn, subject string) ([]string, error) {
+	names := make([]string, 0)
+	for {
+		var page struct {
+			Total   int      `json:"total"`
+			Streams []string `json:"streams"`
+		}
+		req := map[string]interface{}{"subject": subject, "offset": len(names)}
+		if err := JSRequest(nc, "STREAM.NAMES", req, &page); err != nil {
+			return nil, err
+		}
+		names = append(names, page.Streams...)
+		if len(page.Streams) == 0 || len(names) >= page.Total {
+			return names, nil
+		}
+	}
+}
+
+// PauseConsumer stops deliveries of the consumer until the given time, a zero
+// time resumes it. Pausing needs nats-server 2.11 or later.
+func PauseConsumer(nc *nats.Conn, stream string, consumer string, until time.Time) error {
+	req := map[string]interface{}{}
+	if !until.IsZero() {
+		req["pause_until"] = until.UTC()
+	}
+	return JSRequest(nc, "CONSUMER.PAUSE."+stream+"."+consumer, req, nil)
+}
+
+// StoredMsg is a message as returned by the stream message get API
+type StoredMsg struct {
+	Subject  string    `json:"subject"`
+	Sequence uint64    `json:"seq"`
+	Header   []byte    `json:"hdrs,omitempty"`
+	Data     []byte    `json:"data,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// GetNextMsg returns the first message with a sequence of at least seq whose
+// subject matches filter, skipping deleted messages
+func GetNextMsg(nc *nats.Conn, stream string, seq uint64, filter string) (*StoredMsg, error) {
+	if filter == "" {
+		filter = ">"
+	}
+	return getMsg(nc, stream, map[string]interface{}{"seq": seq, "next_by_subj": filter})
+}
+
+// GetLastMsg returns the latest message stored for subject
+func GetLastMsg(nc *nats.Conn, stream string, subject string) (*StoredMsg, error) {
+	return getMsg(nc, stream, map[string]interface{}{"last_by_subj": subject})
+}
+
+func getMsg(nc *nats.Conn, stream string, req map[string]interface{}) (*StoredMsg, error) {
+	var resp struct {
+		Message *StoredMsg `json:"message"`
+	}
+	if err := JSRequest(nc, "STREAM.MSG.GET."+stream, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Message == nil {
+		return nil, fmt.Errorf("no message in response")
+	}
+	return resp.Message, nil
+}
+
+// IsNotFound reports whether err is the API error for a missing message
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.Code == 404
+}
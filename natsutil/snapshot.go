@@ -0,0 +1,151 @@
+package natsutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Stream snapshots use the same protocol as `nats stream backup/restore`: the
+// server streams s2 compressed tar chunks to an inbox, each chunk is acked to
+// keep the flow going and an empty message marks the end.
+
+const (
+	snapshotChunkSize   = 128 * 1024
+	snapshotIdleTimeout = 30 * time.Second
+	restoreDoneTimeout  = 5 * time.Minute
+)
+
+// SnapshotMeta is the stream configuration and state captured with a snapshot
+type SnapshotMeta struct {
+	Config json.RawMessage `json:"config"`
+	State  json.RawMessage `json:"state"`
+}
+
+// SnapshotStream writes a snapshot of the stream to w. progress is called
+// with the number of bytes received so far.
+func SnapshotStream(nc *nats.Conn, stream string, includeConsumers bool, w io.Writer, progress func(received int64)) (*SnapshotMeta, error) {
+	inbox := nc.NewInbox()
+	done := make(chan error, 1)
+	activity := make(chan struct{}, 1)
+	var received int64
+	finish := func(err error) {
+		select {
+		case done <- err:
+		default:
+		}
+	}
+
+	sub, err := nc.Subscribe(inbox, func(m *nats.Msg) {
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+		if len(m.Data) == 0 {
+			// End of the snapshot, errors are reported in the status header
+			if status := m.Header.Get("Status"); status != "" && status != "204" {
+				finish(fmt.Errorf("snapshot failed: %s %s", status, m.Header.Get("Description")))
+			} else {
+				finish(nil)
+			}
+			return
+		}
+		if _, err := w.Write(m.Data); err != nil {
+			finish(err)
+			return
+		}
+		received += int64(len(m.Data))
+		if progress != nil {
+			progress(received)
+		}
+		m.Respond(nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	req := map[string]interface{}{
+		"deliver_subject": inbox,
+		"no_consumers":    !includeConsumers,
+		"chunk_size":      snapshotChunkSize,
+	}
+	var meta SnapshotMeta
+	if err := JSRequest(nc, "STREAM.SNAPSHOT."+stream, req, &meta); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return nil, err
+			}
+			return &meta, nil
+		case <-activity:
+		case <-time.After(snapshotIdleTimeout):
+			return nil, fmt.Errorf("snapshot stalled, no data received for %s", snapshotIdleTimeout)
+		}
+	}
+}
+
+// RestoreStream restores a snapshot read from r. The stream name is taken
+// from the configuration in meta. progress is called with the bytes sent.
+func RestoreStream(nc *nats.Conn, meta *SnapshotMeta, r io.Reader, progress func(sent int64)) error {
+	var cfg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(meta.Config, &cfg); err != nil {
+		return fmt.Errorf("invalid stream configuration: %v", err)
+	}
+	if cfg.Name == "" {
+		return fmt.Errorf("stream configuration has no name")
+	}
+
+	var resp struct {
+		DeliverSubject string `json:"deliver_subject"`
+	}
+	if err := JSRequest(nc, "STREAM.RESTORE."+cfg.Name, meta, &resp); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, snapshotChunkSize)
+	var sent int64
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			if _, err := nc.Request(resp.DeliverSubject, chunk[:n], apiTimeout); err != nil {
+				return fmt.Errorf("sending snapshot data failed: %v", err)
+			}
+			sent += int64(n)
+			if progress != nil {
+				progress(sent)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// An empty message finishes the restore, the reply is the stream create response
+	msg, err := nc.Request(resp.DeliverSubject, nil, restoreDoneTimeout)
+	if err != nil {
+		return fmt.Errorf("finishing restore failed: %v", err)
+	}
+	var result struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(msg.Data, &result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+)
+
+// publishComposer holds the publish inputs shared by NatsPage and
+// StreamViewPage, along with the per-context templates and send history.
+// Inputs are sent verbatim unless template mode is on, which a saved
+// template turns on and Alt+T toggles.
+type publishComposer struct {
+	subject      *tview.InputField
+	headers      *tview.InputField
+	body         *tview.TextArea
+	history      []ds.PublishHistoryEntry
+	historyPos   int
+	seq          int
+	templateMode bool
+	blockedKey   string // inputs of the last send blocked by a schema violation
+	log          func(message string)
+	onFill       func() // called before a template or history entry replaces the inputs
+}
+
+const publishComposerKeys = "[Ctrl+T] Templates | [Ctrl+S] Save Template | [Ctrl+G] History | [Alt+↑/↓] Recall | [Alt+T] Template Mode | [Alt+S] Schemas"
+
+func newPublishComposer(subject *tview.InputField, headers *tview.InputField, body *tview.TextArea, log func(message string)) *publishComposer {
+	return &publishComposer{
+		subject: subject,
+		headers: headers,
+		body:    body,
+		log:     log,
+	}
+}
+
+// reset loads the history of the context that is being opened
+func (pc *publishComposer) reset(ctxName string) {
+	pc.seq = 0
+	pc.templateMode = false
+	history, err := ds.LoadPublishHistory(ctxName)
+	if err != nil {
+		pc.log("WARN: Failed to load publish history: " + err.Error())
+	}
+	pc.history = history
+	pc.historyPos = len(pc.history)
+}
+
+// handleKey processes the composer shortcuts and returns nil when the event was consumed
+func (pc *publishComposer) handleKey(event *tcell.EventKey, ctxName string) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyCtrlT:
+		pc.showTemplatePicker(ctxName)
+		return nil
+	case event.Key() == tcell.KeyCtrlS:
+		pc.promptSaveTemplate(ctxName)
+		return nil
+	case event.Key() == tcell.KeyCtrlG:
+		pc.showHistoryPicker()
+		return nil
+	case event.Key() == tcell.KeyRune && event.Rune() == 't' && event.Modifiers()&tcell.ModAlt != 0:
+		pc.setTemplateMode(!pc.templateMode)
+		return nil
+	case event.Key() == tcell.KeyRune && event.Rune() == 's' && event.Modifiers()&tcell.ModAlt != 0:
+		// Remember where to come back to after editing the schema mappings
+		returnPage, _ := pages.GetFrontPage()
+		pages.SwitchToPage("schemaMapPage")
+		_, b := pages.GetFrontPage()
+		schemaPage := b.(*SchemaMapPage)
+		schemaPage.returnPage = returnPage
+		schemaPage.redraw(&data.CurrCtx)
+		return nil
+	case event.Key() == tcell.KeyUp && event.Modifiers()&tcell.ModAlt != 0:
+		pc.recallHistory(-1)
+		return nil
+	case event.Key() == tcell.KeyDown && event.Modifiers()&tcell.ModAlt != 0:
+		pc.recallHistory(1)
+		return nil
+	}
+	return event
+}
+
+func (pc *publishComposer) fill(subject string, headers map[string]string, body string, template bool) {
+	if pc.onFill != nil {
+		pc.onFill()
+	}
+	pc.subject.SetText(subject)
+	pc.headers.SetText(formatHeaderText(headers))
+	pc.body.SetText(body, true)
+	if pc.templateMode != template {
+		pc.setTemplateMode(template)
+	}
+}
+
+func (pc *publishComposer) setTemplateMode(on bool) {
+	pc.templateMode = on
+	if on {
+		pc.log(`INFO: Template mode on, {{uuid}}, {{now}}, {{seq}}, {{randInt min max}}, {{env "NAME"}} and ${NAME} are expanded`)
+	} else {
+		pc.log("INFO: Template mode off, messages are sent as typed")
+	}
+}
+
+func (pc *publishComposer) recallHistory(delta int) {
+	if len(pc.history) == 0 {
+		return
+	}
+	pc.historyPos += delta
+	if pc.historyPos < 0 {
+		pc.historyPos = 0
+	}
+	if pc.historyPos >= len(pc.history) {
+		// Moving past the newest entry gives an empty composer again
+		pc.historyPos = len(pc.history)
+		pc.fill("", nil, "", false)
+		return
+	}
+	entry := pc.history[pc.historyPos]
+	pc.fill(entry.Subject, entry.Headers, entry.Body, entry.Template)
+}
+
+func (pc *publishComposer) showHistoryPicker() {
+	if len(pc.history) == 0 {
+		pc.log("INFO: Publish history is empty")
+		return
+	}
+	// Most recent first
+	items := make([]pickerItem, 0, len(pc.history))
+	for i := len(pc.history) - 1; i >= 0; i-- {
+		entry := pc.history[i]
+		items = append(items, pickerItem{
+			Text:      entry.Subject + "  " + previewText(entry.Body, 60),
+			Secondary: entry.Time.Format("2006-01-02 15:04:05") + "  " + formatHeaderText(entry.Headers),
+		})
+	}
+	showPickerModal("Publish History", items, func(idx int) {
+		pc.historyPos = len(pc.history) - 1 - idx
+		entry := pc.history[pc.historyPos]
+		pc.fill(entry.Subject, entry.Headers, entry.Body, entry.Template)
+	})
+}
+
+func (pc *publishComposer) showTemplatePicker(ctxName string) {
+	templates, err := ds.LoadPublishTemplates(ctxName)
+	if err != nil {
+		pc.log("ERROR: Failed to load templates: " + err.Error())
+		return
+	}
+	if len(templates) == 0 {
+		pc.log("INFO: No templates saved yet, use Ctrl+S to save the current message")
+		return
+	}
+	items := make([]pickerItem, 0, len(templates))
+	for _, t := range templates {
+		items = append(items, pickerItem{
+			Text:      t.Name,
+			Secondary: t.Subject + "  " + previewText(t.Body, 60),
+		})
+	}
+	showPickerModal("Publish Templates", items, func(idx int) {
+		t := templates[idx]
+		pc.fill(t.Subject, t.Headers, t.Body, true)
+	})
+}
+
+func (pc *publishComposer) promptSaveTemplate(ctxName string) {
+	showPromptModal("Save Template", "Template name: ", "", func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			pc.log("ERROR: Template name cannot be empty")
+			return
+		}
+		tmpl := ds.PublishTemplate{
+			Name:    name,
+			Subject: pc.subject.GetText(),
+			Headers: parseHeaderText(pc.headers.GetText()),
+			Body:    pc.body.GetText(),
+		}
+		if err := ds.SavePublishTemplate(ctxName, tmpl); err != nil {
+			pc.log("ERROR: Failed to save template: " + err.Error())
+			return
+		}
+		pc.log("INFO: Saved template " + name)
+	})
+}
+
+// buildMsg assembles the message of the current inputs. In template mode the
+// template variables are expanded and each call advances {{seq}}.
+func (pc *publishComposer) buildMsg() (*nats.Msg, error) {
+	subject, headers, body := pc.subject.GetText(), parseHeaderText(pc.headers.GetText()), pc.body.GetText()
+	if !pc.templateMode {
+		msg := nats.NewMsg(subject)
+		for k, v := range headers {
+			msg.Header.Set(k, v)
+		}
+		msg.Data = []byte(body)
+		return msg, nil
+	}
+	pc.seq++
+	return buildPublishMsg(subject, headers, body, pc.seq)
+}
+
+// buildFileMsg assembles the subject and headers like buildMsg but sends
+// data as the body without any expansion
+func (pc *publishComposer) buildFileMsg(data []byte) (*nats.Msg, error) {
+	msg, err := pc.buildMsg()
+	if err != nil {
+		return nil, err
+	}
+	msg.Data = data
+	return msg, nil
+}
+
+// checkSchema validates the message against the JSON Schemas mapped to its
+// subject and reports violations. Sending the same inputs again forces the publish.
+func (pc *publishComposer) checkSchema(ctxName string, msg *nats.Msg) bool {
+	matched, violations := getSchemaRegistry(ctxName).validate(msg.Subject, msg.Data)
+	if !matched || len(violations) == 0 {
+		pc.blockedKey = ""
+		return true
+	}
+
+	key := pc.subject.GetText() + "\x00" + pc.headers.GetText() + "\x00" + pc.body.GetText()
+	if pc.blockedKey == key {
+		pc.blockedKey = ""
+		pc.log("WARN: Publishing to " + msg.Subject + " despite schema violations")
+		return true
+	}
+	pc.blockedKey = key
+	for _, v := range violations {
+		pc.log("SCHEMA[" + msg.Subject + "] " + v.String())
+	}
+	pc.log("ERROR: Payload violates the schema, press Alt+Enter again to publish anyway")
+	return false
+}
+
+// checkBatchSchema validates every message of a batch file and reports the
+// violations. Publishing the same file again forces the publish.
+func (pc *publishComposer) checkBatchSchema(ctxName string, filePath string, msgs []*nats.Msg) bool {
+	reg := getSchemaRegistry(ctxName)
+	invalid := 0
+	for i, msg := range msgs {
+		matched, violations := reg.validate(msg.Subject, msg.Data)
+		if !matched || len(violations) == 0 {
+			continue
+		}
+		invalid++
+		pc.log(fmt.Sprintf("BATCH #%d SCHEMA[%s] %s", i+1, msg.Subject, formatViolations(violations)))
+	}
+	if invalid == 0 {
+		pc.blockedKey = ""
+		return true
+	}
+
+	key := "batch\x00" + filePath
+	if pc.blockedKey == key {
+		pc.blockedKey = ""
+		pc.log(fmt.Sprintf("WARN: Publishing %s despite %d schema violations", filePath, invalid))
+		return true
+	}
+	pc.blockedKey = key
+	pc.log(fmt.Sprintf("ERROR: %d of %d messages violate their schema, publish the batch again to send anyway", invalid, len(msgs)))
+	return false
+}
+
+// schemaViolationLine returns a marker line for a received message that
+// doesn't conform to its schema, or "" when it conforms or has no schema
+func schemaViolationLine(ctxName string, msg *nats.Msg) string {
+	matched, violations := getSchemaRegistry(ctxName).validate(msg.Subject, msg.Data)
+	if !matched || len(violations) == 0 {
+		return ""
+	}
+	return "!SCHEMA[" + msg.Subject + "] " + formatViolations(violations)
+}
+
+// recordSend stores the unexpanded inputs in the context's history
+func (pc *publishComposer) recordSend(ctxName string) {
+	entry := ds.PublishHistoryEntry{
+		Subject:  pc.subject.GetText(),
+		Headers:  parseHeaderText(pc.headers.GetText()),
+		Body:     pc.body.GetText(),
+		Template: pc.templateMode,
+		Time:     time.Now(),
+	}
+	history, err := ds.AppendPublishHistory(ctxName, entry)
+	if err != nil {
+		pc.log("WARN: Failed to save publish history: " + err.Error())
+		return
+	}
+	pc.history = history
+	pc.historyPos = len(pc.history)
+}
+
+func previewText(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > max {
+		return fmt.Sprintf("%s...", text[:max])
+	}
+	return text
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+var envVarRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandPublishTemplate substitutes template variables in a subject, header
+// or body. Supported are {{uuid}}, {{now}} or {{now "layout"}}, {{seq}},
+// {{randInt min max}}, {{env "NAME"}} and ${NAME} environment variables.
+// An unset ${NAME} is an error rather than an empty string.
+func expandPublishTemplate(text string, seq int) (string, error) {
+	var missing string
+	text = envVarRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := envVarRe.FindStringSubmatch(match)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set", missing)
+	}
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	funcs := template.FuncMap{
+		"uuid": newUUID,
+		"now": func(layout ...string) string {
+			if len(layout) > 0 {
+				return time.Now().Format(layout[0])
+			}
+			return time.Now().Format(time.RFC3339Nano)
+		},
+		"seq": func() int { return seq },
+		"randInt": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("randInt: max %d is lower than min %d", max, min)
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+			if err != nil {
+				return 0, err
+			}
+			return min + int(n.Int64()), nil
+		},
+		"env": os.Getenv,
+	}
+	tmpl, err := template.New("publish").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// parseHeaderText parses headers typed as "Key=value; Other=value"
+func parseHeaderText(text string) map[string]string {
+	headers := make(map[string]string)
+	for _, part := range strings.Split(text, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			continue
+		}
+		value := ""
+		if len(kv) == 2 {
+			value = strings.TrimSpace(kv[1])
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+func formatHeaderText(headers map[string]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+headers[k])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// buildPublishMsg expands the template variables and assembles the message
+func buildPublishMsg(subject string, headers map[string]string, body string, seq int) (*nats.Msg, error) {
+	subject, err := expandPublishTemplate(subject, seq)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %v", err)
+	}
+	msg := nats.NewMsg(subject)
+	for k, v := range headers {
+		expanded, err := expandPublishTemplate(v, seq)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %v", k, err)
+		}
+		msg.Header.Set(k, expanded)
+	}
+	expanded, err := expandPublishTemplate(body, seq)
+	if err != nil {
+		return nil, fmt.Errorf("body: %v", err)
+	}
+	msg.Data = []byte(expanded)
+	return msg, nil
+}
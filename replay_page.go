@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+)
+
+const (
+	replayModeCore      = "Core publish"
+	replayModeJetStream = "JetStream publish"
+
+	replayTimingOriginal = "Original timing"
+	replayTimingMax      = "Max speed"
+	replayTimingFixed    = "Fixed rate"
+)
+
+type ReplayPage struct {
+	*tview.Flex
+	Data      *ds.Data
+	app       *tview.Application
+	form      *tview.Form
+	statsView *tview.TextView
+	logView   *tview.TextView
+	footerTxt *tview.TextView
+	stopCh    chan struct{}
+	runningMu sync.Mutex
+
+	sent   atomic.Int64
+	acked  atomic.Int64
+	errors atomic.Int64
+	total  int
+}
+
+func NewReplayPage(app *tview.Application, data *ds.Data) *ReplayPage {
+	rp := &ReplayPage{
+		Flex: tview.NewFlex().SetDirection(tview.FlexRow),
+		app:  app,
+		Data: data,
+	}
+	rp.setupUI()
+	rp.setupInputCapture()
+	return rp
+}
+
+func (rp *ReplayPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back  |  [Tab] Focus Next  |  Rules: prefix <from> <to>; token <pos> <from|*> <to>", tcell.ColorWhite), 0, 1, false)
+	rp.AddItem(headerRow, 3, 1, false)
+
+	// Replay options
+	rp.form = tview.NewForm()
+	rp.form.SetBorder(true)
+	rp.form.SetTitle("Replay Capture")
+	rp.form.AddInputField("Capture File", "", 0, nil, nil)
+	rp.form.AddDropDown("Publish Mode", []string{replayModeCore, replayModeJetStream}, 0, nil)
+	rp.form.AddDropDown("Timing", []string{replayTimingOriginal, replayTimingMax, replayTimingFixed}, 0, nil)
+	rp.form.AddInputField("Rate (msgs/sec)", "100", 10, tview.InputFieldInteger, nil)
+	rp.form.AddInputField("Rewrite Rules", "", 0, nil, nil)
+	rp.form.AddCheckbox("Dry Run", false, nil)
+	rp.form.AddButton("Start", rp.startReplay)
+	rp.form.AddButton("Stop", rp.stopReplay)
+	rp.AddItem(rp.form, 17, 1, true)
+
+	// Progress
+	rp.statsView = createTextView("", tcell.ColorWhite)
+	rp.statsView.SetBorder(true)
+	rp.statsView.SetTitle("Progress")
+	rp.AddItem(rp.statsView, 3, 1, false)
+
+	// Ack errors and dry run output
+	rp.logView = tview.NewTextView()
+	rp.logView.SetBorder(true)
+	rp.logView.SetTitle("Output")
+	rp.AddItem(rp.logView, 0, 1, false)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	rp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(rp.footerTxt, 0, 1, false)
+	rp.AddItem(footer, 3, 1, false)
+
+	rp.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (rp *ReplayPage) setupInputCapture() {
+	rp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			rp.goBack()
+			return nil
+		}
+		return event
+	})
+}
+
+func (rp *ReplayPage) redraw(ctx *ds.Context) {
+	rp.form.SetTitle("Replay Capture into " + ctx.Name)
+	rp.app.SetFocus(rp.form)
+	go rp.app.Draw()
+}
+
+func (rp *ReplayPage) goBack() {
+	rp.stopReplay()
+	pages.SwitchToPage("natsPage")
+	_, b := pages.GetFrontPage()
+	rp.app.SetFocus(b.(*NatsPage).subjectName)
+}
+
+func (rp *ReplayPage) startReplay() {
+	rp.runningMu.Lock()
+	defer rp.runningMu.Unlock()
+	if rp.stopCh != nil {
+		rp.notify("A replay is already running", 3*time.Second, "warn")
+		return
+	}
+
+	filePath := rp.form.GetFormItemByLabel("Capture File").(*tview.InputField).GetText()
+	_, mode := rp.form.GetFormItemByLabel("Publish Mode").(*tview.DropDown).GetCurrentOption()
+	_, timing := rp.form.GetFormItemByLabel("Timing").(*tview.DropDown).GetCurrentOption()
+	rateTxt := rp.form.GetFormItemByLabel("Rate (msgs/sec)").(*tview.InputField).GetText()
+	rulesTxt := rp.form.GetFormItemByLabel("Rewrite Rules").(*tview.InputField).GetText()
+	dryRun := rp.form.GetFormItemByLabel("Dry Run").(*tview.Checkbox).IsChecked()
+
+	msgs, err := loadCapturedMessages(filePath)
+	if err != nil {
+		rp.notify("Failed to load capture: "+err.Error(), 5*time.Second, "error")
+		return
+	}
+	rules, err := parseRewriteRules(rulesTxt)
+	if err != nil {
+		rp.notify("Invalid rewrite rules: "+err.Error(), 5*time.Second, "error")
+		return
+	}
+	rate, _ := strconv.Atoi(rateTxt)
+	if timing == replayTimingFixed && rate <= 0 {
+		rp.notify("Rate must be greater than zero", 3*time.Second, "error")
+		return
+	}
+
+	var js nats.JetStreamContext
+	if mode == replayModeJetStream && !dryRun {
+		js, err = rp.Data.CurrCtx.Conn.JetStream()
+		if err != nil {
+			rp.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
+			return
+		}
+	}
+
+	rp.logView.Clear()
+	rp.sent.Store(0)
+	rp.acked.Store(0)
+	rp.errors.Store(0)
+	rp.total = len(msgs)
+	rp.stopCh = make(chan struct{})
+	logger.Info("Replaying %d messages from %s (mode=%s timing=%s dry-run=%v)", len(msgs), filePath, mode, timing, dryRun)
+	rp.notify(fmt.Sprintf("Replaying %d messages...", len(msgs)), 3*time.Second, "info")
+
+	go rp.runReplay(msgs, rules, js, timing, rate, dryRun, rp.stopCh)
+}
+
+func (rp *ReplayPage) stopReplay() {
+	rp.runningMu.Lock()
+	defer rp.runningMu.Unlock()
+	if rp.stopCh != nil {
+		close(rp.stopCh)
+		rp.stopCh = nil
+	}
+}
+
+func (rp *ReplayPage) runReplay(msgs []capturedMsg, rules []subjectRewriteRule, js nats.JetStreamContext, timing string, rate int, dryRun bool, stopCh chan struct{}) {
+	started := time.Now()
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				rp.updateStats(started)
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	// JetStream acks are checked on a separate goroutine so publishing is not
+	// held back by round trips
+	var acks sync.WaitGroup
+	futures := make(chan replayAck, 1024)
+	if js != nil {
+		acks.Add(1)
+		go func() {
+			defer acks.Done()
+			for f := range futures {
+				select {
+				case <-f.future.Ok():
+					rp.acked.Add(1)
+				case err := <-f.future.Err():
+					rp.errors.Add(1)
+					rp.output(fmt.Sprintf("ACK ERROR #%d [%s]: %v", f.index, f.subject, err))
+				}
+			}
+		}()
+	}
+
+	interval := time.Duration(0)
+	if timing == replayTimingFixed {
+		interval = time.Second / time.Duration(rate)
+	}
+	next := time.Now()
+
+	stopped := false
+	for i, captured := range msgs {
+		select {
+		case <-stopCh:
+			stopped = true
+		default:
+		}
+		if stopped {
+			break
+		}
+
+		// Wait according to the selected timing mode
+		var wait time.Duration
+		switch timing {
+		case replayTimingOriginal:
+			if i > 0 && !captured.Time.IsZero() && !msgs[i-1].Time.IsZero() {
+				wait = captured.Time.Sub(msgs[i-1].Time)
+			}
+			if wait < 0 {
+				wait = 0 // out of order timestamps are sent right away
+			}
+		case replayTimingFixed:
+			next = next.Add(interval)
+			wait = time.Until(next)
+		}
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-stopCh:
+				stopped = true
+			}
+			if stopped {
+				break
+			}
+		}
+
+		msg := nats.NewMsg(rewriteSubject(captured.Subject, rules))
+		msg.Header = captured.Header
+		msg.Data = captured.Data
+
+		if dryRun {
+			rp.output(fmt.Sprintf("#%d %s -> %s (%d bytes, %d headers)", i+1, captured.Subject, msg.Subject, len(msg.Data), len(msg.Header)))
+			rp.sent.Add(1)
+			continue
+		}
+
+		if js != nil {
+			f, err := js.PublishMsgAsync(msg)
+			if err != nil {
+				rp.errors.Add(1)
+				rp.output(fmt.Sprintf("PUB ERROR #%d [%s]: %v", i+1, msg.Subject, err))
+				continue
+			}
+			futures <- replayAck{index: i + 1, subject: msg.Subject, future: f}
+		} else if err := rp.Data.CurrCtx.Conn.PublishMsg(msg); err != nil {
+			rp.errors.Add(1)
+			rp.output(fmt.Sprintf("PUB ERROR #%d [%s]: %v", i+1, msg.Subject, err))
+			continue
+		}
+		rp.sent.Add(1)
+	}
+
+	close(futures)
+	acks.Wait()
+	if js == nil && !dryRun {
+		rp.Data.CurrCtx.Conn.Flush()
+	}
+
+	rp.updateStats(started)
+	summary := fmt.Sprintf("Replay finished: %d sent, %d errors in %s", rp.sent.Load(), rp.errors.Load(), time.Since(started).Round(time.Millisecond))
+	if stopped {
+		summary = fmt.Sprintf("Replay stopped after %d of %d messages", rp.sent.Load(), rp.total)
+	}
+	rp.output(summary)
+	hourMinSec := time.Now().Format("15:04:05.00000")
+	if rp.Data.CurrCtx.LogFile != nil {
+		rp.Data.CurrCtx.LogFile.WriteString(hourMinSec + " REPLAY " + summary + "\n")
+	}
+	logger.Info(summary)
+
+	rp.runningMu.Lock()
+	if rp.stopCh == stopCh {
+		close(rp.stopCh)
+		rp.stopCh = nil
+	}
+	rp.runningMu.Unlock()
+}
+
+type replayAck struct {
+	index   int
+	subject string
+	future  nats.PubAckFuture
+}
+
+func (rp *ReplayPage) updateStats(started time.Time) {
+	elapsed := time.Since(started).Seconds()
+	sent := rp.sent.Load()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(sent) / elapsed
+	}
+	stats := fmt.Sprintf("Sent: %d/%d  |  Acked: %d  |  Errors: %d  |  Rate: %.1f msgs/sec",
+		sent, rp.total, rp.acked.Load(), rp.errors.Load(), rate)
+	rp.app.QueueUpdateDraw(func() {
+		rp.statsView.SetText(stats)
+	})
+}
+
+func (rp *ReplayPage) output(line string) {
+	rp.app.QueueUpdateDraw(func() {
+		rp.logView.Write([]byte(line + "\n"))
+		rp.logView.ScrollToEnd()
+	})
+}
+
+func (rp *ReplayPage) notify(message string, duration time.Duration, logLevel string) {
+	rp.footerTxt.SetText(message)
+	rp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		rp.footerTxt.SetText("")
+		rp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
@@ -0,0 +1,414 @@
+// Package schema implements the commonly used subset of JSON Schema
+// (draft 7 / 2020-12) needed to check message payloads: type, enum, const,
+// properties, required, additionalProperties, items, string, number and
+// array bounds, pattern, a few formats, allOf/anyOf/oneOf/not and local $refs.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// Parse decodes a JSON Schema document
+func Parse(content []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, fmt.Errorf("schema must be an object or boolean")
+	}
+	return &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}, nil
+}
+
+// ValidateJSON checks a raw payload. A payload that isn't JSON at all is
+// reported as a single violation at the document root.
+func (s *Schema) ValidateJSON(payload []byte) []Violation {
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return []Violation{{Path: "$", Message: "payload is not valid JSON: " + err.Error()}}
+	}
+	return s.Validate(doc)
+}
+
+// Validate checks an already decoded document
+func (s *Schema) Validate(doc interface{}) []Violation {
+	violations := make([]Violation, 0)
+	s.validate(s.root, doc, "$", &violations, 0)
+	return violations
+}
+
+func (s *Schema) validate(node interface{}, value interface{}, path string, out *[]Violation, depth int) {
+	if depth > 64 {
+		*out = append(*out, Violation{path, "schema nesting too deep (recursive $ref?)"})
+		return
+	}
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			*out = append(*out, Violation{path, "no value is allowed here"})
+		}
+		return
+	case map[string]interface{}:
+		s.validateObjectSchema(n, value, path, out, depth)
+	}
+}
+
+func (s *Schema) validateObjectSchema(n map[string]interface{}, value interface{}, path string, out *[]Violation, depth int) {
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := s.resolveRef(ref)
+		if err != nil {
+			*out = append(*out, Violation{path, err.Error()})
+			return
+		}
+		s.validate(target, value, path, out, depth+1)
+	}
+
+	if t, ok := n["type"]; ok && !matchesType(t, value) {
+		*out = append(*out, Violation{path, fmt.Sprintf("expected %s, got %s", typeNames(t), jsonType(value))})
+		return
+	}
+
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*out = append(*out, Violation{path, fmt.Sprintf("value must be one of %s", compactJSON(enum))})
+		}
+	}
+	if c, ok := n["const"]; ok && !jsonEqual(c, value) {
+		*out = append(*out, Violation{path, fmt.Sprintf("value must be %s", compactJSON(c))})
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(n, v, path, out, depth)
+	case []interface{}:
+		s.validateArray(n, v, path, out, depth)
+	case string:
+		validateString(n, v, path, out, s)
+	case float64:
+		validateNumber(n, v, path, out)
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			s.validate(sub, value, path, out, depth+1)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		if s.countMatches(anyOf, value, path, depth) == 0 {
+			*out = append(*out, Violation{path, "value does not match any of the allowed schemas"})
+		}
+	}
+	if oneOf, ok := n["oneOf"].([]interface{}); ok {
+		if matches := s.countMatches(oneOf, value, path, depth); matches != 1 {
+			*out = append(*out, Violation{path, fmt.Sprintf("value must match exactly one schema, matched %d", matches)})
+		}
+	}
+	if not, ok := n["not"]; ok {
+		sub := make([]Violation, 0)
+		s.validate(not, value, path, &sub, depth+1)
+		if len(sub) == 0 {
+			*out = append(*out, Violation{path, "value must not match the schema"})
+		}
+	}
+}
+
+func (s *Schema) countMatches(schemas []interface{}, value interface{}, path string, depth int) int {
+	matches := 0
+	for _, sub := range schemas {
+		subViolations := make([]Violation, 0)
+		s.validate(sub, value, path, &subViolations, depth+1)
+		if len(subViolations) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func (s *Schema) validateObject(n map[string]interface{}, obj map[string]interface{}, path string, out *[]Violation, depth int) {
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				*out = append(*out, Violation{childPath(path, name), "required property is missing"})
+			}
+		}
+	}
+
+	props, _ := n["properties"].(map[string]interface{})
+	patternProps, _ := n["patternProperties"].(map[string]interface{})
+
+	// Iterate in a stable order so violations are reported deterministically
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		matched := false
+		if sub, ok := props[k]; ok {
+			matched = true
+			s.validate(sub, obj[k], childPath(path, k), out, depth+1)
+		}
+		for pattern, sub := range patternProps {
+			if re := s.regexp(pattern); re != nil && re.MatchString(k) {
+				matched = true
+				s.validate(sub, obj[k], childPath(path, k), out, depth+1)
+			}
+		}
+		if matched {
+			continue
+		}
+		if additional, ok := n["additionalProperties"]; ok {
+			if b, isBool := additional.(bool); isBool && !b {
+				*out = append(*out, Violation{childPath(path, k), "additional property is not allowed"})
+			} else if !isBool {
+				s.validate(additional, obj[k], childPath(path, k), out, depth+1)
+			}
+		}
+	}
+
+	if min, ok := number(n["minProperties"]); ok && float64(len(obj)) < min {
+		*out = append(*out, Violation{path, fmt.Sprintf("must have at least %v properties", min)})
+	}
+	if max, ok := number(n["maxProperties"]); ok && float64(len(obj)) > max {
+		*out = append(*out, Violation{path, fmt.Sprintf("must have at most %v properties", max)})
+	}
+}
+
+func (s *Schema) validateArray(n map[string]interface{}, arr []interface{}, path string, out *[]Violation, depth int) {
+	// "prefixItems" (2020-12) and array-form "items" (draft 7) validate by position
+	prefix, _ := n["prefixItems"].([]interface{})
+	if tuple, ok := n["items"].([]interface{}); ok {
+		prefix = tuple
+	}
+	for i, item := range arr {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(prefix) {
+			s.validate(prefix[i], item, itemPath, out, depth+1)
+			continue
+		}
+		if items, ok := n["items"]; ok {
+			if _, isTuple := items.([]interface{}); !isTuple {
+				s.validate(items, item, itemPath, out, depth+1)
+			}
+		}
+	}
+
+	if min, ok := number(n["minItems"]); ok && float64(len(arr)) < min {
+		*out = append(*out, Violation{path, fmt.Sprintf("must have at least %v items", min)})
+	}
+	if max, ok := number(n["maxItems"]); ok && float64(len(arr)) > max {
+		*out = append(*out, Violation{path, fmt.Sprintf("must have at most %v items", max)})
+	}
+	if unique, ok := n["uniqueItems"].(bool); ok && unique {
+		for i := 0; i < len(arr); i++ {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					*out = append(*out, Violation{path, fmt.Sprintf("items %d and %d are equal", i, j)})
+				}
+			}
+		}
+	}
+}
+
+func validateString(n map[string]interface{}, str string, path string, out *[]Violation, s *Schema) {
+	length := float64(len([]rune(str)))
+	if min, ok := number(n["minLength"]); ok && length < min {
+		*out = append(*out, Violation{path, fmt.Sprintf("must be at least %v characters", min)})
+	}
+	if max, ok := number(n["maxLength"]); ok && length > max {
+		*out = append(*out, Violation{path, fmt.Sprintf("must be at most %v characters", max)})
+	}
+	if pattern, ok := n["pattern"].(string); ok {
+		if re := s.regexp(pattern); re != nil && !re.MatchString(str) {
+			*out = append(*out, Violation{path, fmt.Sprintf("does not match pattern %q", pattern)})
+		}
+	}
+	if format, ok := n["format"].(string); ok {
+		if msg := checkFormat(format, str); msg != "" {
+			*out = append(*out, Violation{path, msg})
+		}
+	}
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func checkFormat(format, str string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return "is not a valid RFC 3339 date-time"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return "is not a valid date"
+		}
+	case "email":
+		if _, err := mail.ParseAddress(str); err != nil {
+			return "is not a valid email address"
+		}
+	case "uuid":
+		if !uuidRe.MatchString(str) {
+			return "is not a valid UUID"
+		}
+	}
+	return ""
+}
+
+func validateNumber(n map[string]interface{}, num float64, path string, out *[]Violation) {
+	if min, ok := number(n["minimum"]); ok && num < min {
+		*out = append(*out, Violation{path, fmt.Sprintf("must be >= %v", min)})
+	}
+	if max, ok := number(n["maximum"]); ok && num > max {
+		*out = append(*out, Violation{path, fmt.Sprintf("must be <= %v", max)})
+	}
+	if min, ok := number(n["exclusiveMinimum"]); ok && num <= min {
+		*out = append(*out, Violation{path, fmt.Sprintf("must be > %v", min)})
+	}
+	if max, ok := number(n["exclusiveMaximum"]); ok && num >= max {
+		*out = append(*out, Violation{path, fmt.Sprintf("must be < %v", max)})
+	}
+	if mult, ok := number(n["multipleOf"]); ok && mult > 0 {
+		if q := num / mult; math.Abs(q-math.Round(q)) > 1e-9 {
+			*out = append(*out, Violation{path, fmt.Sprintf("must be a multiple of %v", mult)})
+		}
+	}
+}
+
+// resolveRef supports local JSON pointers such as "#/definitions/order" or "#/$defs/order"
+func (s *Schema) resolveRef(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q, only local references are supported", ref)
+	}
+	var node interface{} = s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *Schema) regexp(pattern string) *regexp.Regexp {
+	if re, ok := s.patterns[pattern]; ok {
+		return re
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	s.patterns[pattern] = re
+	return re
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []interface{}:
+		for _, item := range tt {
+			if name, ok := item.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(name string, value interface{}) bool {
+	actual := jsonType(value)
+	if name == "number" && actual == "integer" {
+		return true
+	}
+	return name == actual
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func typeNames(t interface{}) string {
+	if arr, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(arr))
+		for _, a := range arr {
+			names = append(names, fmt.Sprint(a))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func childPath(path, key string) string {
+	return path + "." + key
+}
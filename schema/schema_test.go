@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		payload string
+		want    []Violation
+	}{
+		{
+			name:    "type matches",
+			schema:  `{"type": "object"}`,
+			payload: `{"id": 1}`,
+			want:    []Violation{},
+		},
+		{
+			name:    "type mismatch",
+			schema:  `{"type": "string"}`,
+			payload: `42`,
+			want:    []Violation{{"$", "expected string, got integer"}},
+		},
+		{
+			name:    "integer is a number",
+			schema:  `{"type": "number"}`,
+			payload: `42`,
+			want:    []Violation{},
+		},
+		{
+			name:    "type list",
+			schema:  `{"type": ["string", "null"]}`,
+			payload: `true`,
+			want:    []Violation{{"$", "expected string or null, got boolean"}},
+		},
+		{
+			name:    "required present",
+			schema:  `{"required": ["id", "name"]}`,
+			payload: `{"id": 1, "name": "a"}`,
+			want:    []Violation{},
+		},
+		{
+			name:    "required missing",
+			schema:  `{"required": ["id", "name"]}`,
+			payload: `{"id": 1}`,
+			want:    []Violation{{"$.name", "required property is missing"}},
+		},
+		{
+			name:    "enum allowed",
+			schema:  `{"enum": ["new", "done"]}`,
+			payload: `"done"`,
+			want:    []Violation{},
+		},
+		{
+			name:    "enum not allowed",
+			schema:  `{"enum": ["new", "done"]}`,
+			payload: `"lost"`,
+			want:    []Violation{{"$", `value must be one of ["new","done"]`}},
+		},
+		{
+			name:    "pattern matches",
+			schema:  `{"type": "string", "pattern": "^ORD-[0-9]+$"}`,
+			payload: `"ORD-17"`,
+			want:    []Violation{},
+		},
+		{
+			name:    "pattern does not match",
+			schema:  `{"type": "string", "pattern": "^ORD-[0-9]+$"}`,
+			payload: `"ORD-x"`,
+			want:    []Violation{{"$", `does not match pattern "^ORD-[0-9]+$"`}},
+		},
+		{
+			name:    "invalid pattern is ignored",
+			schema:  `{"pattern": "("}`,
+			payload: `"anything"`,
+			want:    []Violation{},
+		},
+		{
+			name: "ref to definitions",
+			schema: `{
+				"properties": {"item": {"$ref": "#/definitions/item"}},
+				"definitions": {"item": {"type": "object", "required": ["sku"]}}
+			}`,
+			payload: `{"item": {}}`,
+			want:    []Violation{{"$.item.sku", "required property is missing"}},
+		},
+		{
+			name: "ref to $defs",
+			schema: `{
+				"items": {"$ref": "#/$defs/qty"},
+				"$defs": {"qty": {"type": "integer"}}
+			}`,
+			payload: `[1, "two"]`,
+			want:    []Violation{{"$[1]", "expected integer, got string"}},
+		},
+		{
+			name:    "unresolvable ref",
+			schema:  `{"$ref": "#/definitions/missing"}`,
+			payload: `{}`,
+			want:    []Violation{{"$", `unresolvable $ref "#/definitions/missing"`}},
+		},
+		{
+			name:    "remote ref",
+			schema:  `{"$ref": "https://example.com/order.json"}`,
+			payload: `{}`,
+			want:    []Violation{{"$", `unsupported $ref "https://example.com/order.json", only local references are supported`}},
+		},
+		{
+			name:    "recursive ref",
+			schema:  `{"$ref": "#"}`,
+			payload: `{}`,
+			want:    []Violation{{"$", "schema nesting too deep (recursive $ref?)"}},
+		},
+		{
+			name:    "payload is not JSON",
+			schema:  `{"type": "object"}`,
+			payload: `{`,
+			want:    []Violation{{"$", "payload is not valid JSON: unexpected end of JSON input"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got := s.ValidateJSON([]byte(tt.payload))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateJSON(%s) = %v, want %v", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{"object", `{"type": "object"}`, false},
+		{"boolean", `true`, false},
+		{"array", `[]`, true},
+		{"string", `"object"`, true},
+		{"invalid JSON", `{"type":`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%s) error = %v, wantErr %v", tt.schema, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"gopkg.in/yaml.v2"
+)
+
+// SchemaMapPage edits the per-context mapping of subject patterns to JSON Schema files
+type SchemaMapPage struct {
+	*tview.Flex
+	Data       *ds.Data
+	app        *tview.Application
+	txtArea    *tview.TextArea
+	footerTxt  *tview.TextView
+	returnPage string
+}
+
+const schemaMapHelp = `# Subject pattern to JSON Schema mappings for this context.
+# Patterns use NATS wildcards: "*" matches one token, ">" the rest.
+# Payloads published to a matching subject are validated before sending.
+#
+# - subject: "orders.*"
+#   schema: "/path/to/order.schema.json"
+`
+
+func NewSchemaMapPage(app *tview.Application, data *ds.Data) *SchemaMapPage {
+	smp := &SchemaMapPage{
+		Flex:       tview.NewFlex().SetDirection(tview.FlexRow),
+		app:        app,
+		Data:       data,
+		returnPage: "natsPage",
+	}
+
+	// Header
+	headerRow := tview.NewFlex().SetDirection(tview.FlexColumn)
+	headerTxtView := createTextView("[ESC] Back    [Alt+Enter] Save", tcell.ColorWhite)
+	headerTxtView.SetBorderPadding(1, 1, 1, 1)
+	headerRow.AddItem(headerTxtView, 0, 1, false)
+
+	// Mapping editor
+	smp.txtArea = tview.NewTextArea()
+	smp.txtArea.SetBorder(true)
+	smp.txtArea.SetTitle("JSON Schema Mappings (YAML)")
+
+	// Footer
+	smp.footerTxt = createTextView("", tcell.ColorWhite)
+	smp.footerTxt.SetBorder(true)
+
+	smp.AddItem(headerRow, 3, 0, false).
+		AddItem(smp.txtArea, 0, 1, true).
+		AddItem(smp.footerTxt, 3, 0, false)
+
+	smp.setupInputCapture()
+	return smp
+}
+
+func (smp *SchemaMapPage) setupInputCapture() {
+	smp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			smp.goBack()
+			return nil
+		}
+		if event.Key() == tcell.KeyEnter && event.Modifiers() == tcell.ModAlt {
+			smp.save()
+			return nil
+		}
+		return event
+	})
+}
+
+func (smp *SchemaMapPage) redraw(ctx *ds.Context) {
+	smp.txtArea.SetTitle("JSON Schema Mappings (YAML): " + ctx.Name)
+	mappings, err := ds.LoadSchemaMappings(ctx.Name)
+	if err != nil {
+		smp.notify("Failed to load mappings: "+err.Error(), 3*time.Second, "error")
+	}
+
+	text := schemaMapHelp
+	if len(mappings) > 0 {
+		yamlBytes, err := yaml.Marshal(mappings)
+		if err != nil {
+			smp.notify("Failed to convert mappings to YAML: "+err.Error(), 3*time.Second, "error")
+			return
+		}
+		text += "\n" + string(yamlBytes)
+	}
+	smp.txtArea.SetText(text, false)
+	smp.app.SetFocus(smp.txtArea)
+}
+
+func (smp *SchemaMapPage) save() {
+	var mappings []ds.SchemaMapping
+	if err := yaml.UnmarshalStrict([]byte(smp.txtArea.GetText()), &mappings); err != nil {
+		smp.notify("Invalid YAML: "+err.Error(), 5*time.Second, "error")
+		return
+	}
+	for i, m := range mappings {
+		if !isValidSubject(m.Subject) {
+			smp.notify(fmt.Sprintf("Mapping %d: invalid subject pattern %q", i+1, m.Subject), 5*time.Second, "error")
+			return
+		}
+		if _, err := parseSchemaFile(m.Schema); err != nil {
+			smp.notify(fmt.Sprintf("Mapping %d: %v", i+1, err), 5*time.Second, "error")
+			return
+		}
+	}
+
+	if err := ds.SaveSchemaMappings(smp.Data.CurrCtx.Name, mappings); err != nil {
+		smp.notify("Failed to save mappings: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	resetSchemaRegistry(smp.Data.CurrCtx.Name)
+	smp.notify(fmt.Sprintf("Saved %d schema mappings", len(mappings)), 3*time.Second, "info")
+}
+
+func (smp *SchemaMapPage) goBack() {
+	pages.SwitchToPage(smp.returnPage)
+	_, b := pages.GetFrontPage()
+	smp.app.SetFocus(b)
+}
+
+func (smp *SchemaMapPage) notify(message string, duration time.Duration, logLevel string) {
+	smp.footerTxt.SetText(message)
+	smp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		smp.footerTxt.SetText("")
+		smp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
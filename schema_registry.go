@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/schema"
+)
+
+// schemaRegistry validates payloads against the JSON Schemas mapped to their
+// subject. Schema files are checked for changes on disk at most once every
+// schemaRecheckInterval, so validating a message is a map lookup.
+type schemaRegistry struct {
+	mu       sync.Mutex
+	mappings []ds.SchemaMapping
+	cache    map[string]cachedSchema
+}
+
+type cachedSchema struct {
+	modTime   time.Time
+	checkedAt time.Time
+	schema    *schema.Schema
+	err       error
+}
+
+const schemaRecheckInterval = 5 * time.Second
+
+var schemaRegistriesMu sync.Mutex
+var schemaRegistries = map[string]*schemaRegistry{}
+
+// getSchemaRegistry returns the registry of a context, loading its mappings on first use
+func getSchemaRegistry(ctxName string) *schemaRegistry {
+	schemaRegistriesMu.Lock()
+	defer schemaRegistriesMu.Unlock()
+	if reg, ok := schemaRegistries[ctxName]; ok {
+		return reg
+	}
+	mappings, _ := ds.LoadSchemaMappings(ctxName)
+	reg := &schemaRegistry{mappings: mappings, cache: make(map[string]cachedSchema)}
+	schemaRegistries[ctxName] = reg
+	return reg
+}
+
+// resetSchemaRegistry drops the cached registry after its mappings changed
+func resetSchemaRegistry(ctxName string) {
+	schemaRegistriesMu.Lock()
+	delete(schemaRegistries, ctxName)
+	schemaRegistriesMu.Unlock()
+}
+
+// validate returns the violations of every schema mapped to the subject.
+// matched is false when no mapping applies to the subject.
+func (r *schemaRegistry) validate(subject string, payload []byte) (matched bool, violations []schema.Violation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	violations = make([]schema.Violation, 0)
+	for _, m := range r.mappings {
+		if !subjectMatches(m.Subject, subject) {
+			continue
+		}
+		matched = true
+		s, err := r.load(m.Schema)
+		if err != nil {
+			violations = append(violations, schema.Violation{Path: "$", Message: fmt.Sprintf("schema %s: %v", m.Schema, err)})
+			continue
+		}
+		violations = append(violations, s.ValidateJSON(payload)...)
+	}
+	return matched, violations
+}
+
+// load returns the parsed schema file. The file is only stat'ed again once
+// the cached entry is older than schemaRecheckInterval, and re-read when its
+// modification time changed. The caller must hold r.mu.
+func (r *schemaRegistry) load(filePath string) (*schema.Schema, error) {
+	now := time.Now()
+	cached, ok := r.cache[filePath]
+	if ok && now.Sub(cached.checkedAt) < schemaRecheckInterval {
+		return cached.schema, cached.err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		r.cache[filePath] = cachedSchema{checkedAt: now, err: err}
+		return nil, err
+	}
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		cached.checkedAt = now
+		r.cache[filePath] = cached
+		return cached.schema, cached.err
+	}
+	s, err := parseSchemaFile(filePath)
+	r.cache[filePath] = cachedSchema{modTime: info.ModTime(), checkedAt: now, schema: s, err: err}
+	return s, err
+}
+
+// parseSchemaFile reads and parses a schema file without touching any registry
+func parseSchemaFile(filePath string) (*schema.Schema, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Parse(content)
+}
+
+func formatViolations(violations []schema.Violation) string {
+	parts := make([]string, 0, len(violations))
+	for _, v := range violations {
+		parts = append(parts, v.String())
+	}
+	return strings.Join(parts, "; ")
+}
@@ -1,26 +1,26 @@
 package main
 
 import (
-	"encoding/json"
-	"time"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/nats-io/nats.go"
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
-	"github.com/yosuke-furukawa/json5/encoding/json5"
+	"github.com/solidpulse/natsdash/natsutil"
 )
 
 type StreamAddPage struct {
 	*tview.Flex
-	app       *tview.Application
-	Data      *ds.Data
-	textArea  *tview.TextArea
-	footerTxt *tview.TextView
-	isEdit    bool
+	app        *tview.Application
+	Data       *ds.Data
+	editor     *configEditor
+	textArea   *tview.TextArea
+	footerTxt  *tview.TextView
+	isEdit     bool
 	streamName string
+	template   map[string]interface{} // editor values for a new stream
 }
 
 func NewStreamAddPage(app *tview.Application, data *ds.Data) *StreamAddPage {
@@ -40,6 +40,13 @@ func (sap *StreamAddPage) setEditMode(name string) {
 	sap.streamName = name
 }
 
+// setAddMode starts a new stream from the template values
+func (sap *StreamAddPage) setAddMode(template map[string]interface{}) {
+	sap.isEdit = false
+	sap.streamName = ""
+	sap.template = template
+}
+
 func (sap *StreamAddPage) setupUI() {
 	// Header
 	headerRow := tview.NewFlex()
@@ -48,14 +55,26 @@ func (sap *StreamAddPage) setupUI() {
 
 	headerRow.AddItem(createTextView("[ESC] Back", tcell.ColorWhite), 0, 1, false)
 	headerRow.AddItem(createTextView("[Alt+Enter] Save", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Ctrl+O] Mirror/Sources", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Ctrl+S] Save as Template", tcell.ColorWhite), 0, 1, false)
 	headerRow.SetTitle("STREAM CONFIGURATION")
 	sap.AddItem(headerRow, 3, 1, false)
 
-	// Text area for YAML
-	sap.textArea = tview.NewTextArea()
-	sap.textArea.SetBorder(true)
-	sap.textArea.SetTitle("Stream Configuration (YAML)")
+	headerRow2 := tview.NewFlex()
+	headerRow2.SetDirection(tview.FlexColumn)
+	headerRow2.SetBorderPadding(0, 0, 1, 1)
+
+	headerRow2.AddItem(createTextView("[Ctrl+T] JSON5/YAML/JSON", tcell.ColorWhite), 0, 1, false)
+	headerRow2.AddItem(createTextView("[Ctrl+Space] Complete", tcell.ColorWhite), 0, 1, false)
+	headerRow2.AddItem(createTextView("[Ctrl+E] Go to Error", tcell.ColorWhite), 0, 1, false)
+	headerRow2.AddItem(createTextView("[Ctrl+G] External Editor", tcell.ColorWhite), 0, 1, false)
+	sap.AddItem(headerRow2, 1, 1, false)
+
+	// Editor for the configuration, validated while typing
+	sap.editor = newConfigEditor(streamConfigSchema, formatJSON5, "Stream Configuration")
+	sap.textArea = sap.editor.textArea
 	sap.AddItem(sap.textArea, 0, 1, true)
+	sap.AddItem(sap.editor.statusView, 6, 0, false)
 
 	// Footer
 	footer := tview.NewFlex()
@@ -64,60 +83,7 @@ func (sap *StreamAddPage) setupUI() {
 	footer.AddItem(sap.footerTxt, 0, 1, false)
 	sap.AddItem(footer, 3, 1, false)
 
-	userFriendlyJSON5 := `{
-    // Name of the stream (required)
-    name: "my_stream",
-
-    // Description of the stream (optional)
-    description: "My Stream Description",
-
-    // Subjects that messages can be published to (required)
-    // Examples: ["orders.*", "shipping.>", "customer.orders.*"]
-    subjects: [
-        "my.subject.>"
-    ],
-
-    // Storage backend (required)
-    // Possible values: "file", "memory"
-    storage: "file",
-
-    // Number of replicas for the stream
-    // Range: 1-5
-    num_replicas: 1,
-
-    // Retention policy (required)
-    // Possible values: "limits", "interest", "workqueue"
-    retention: "limits",
-
-    // Discard policy when limits are reached
-    // Possible values: "old", "new"
-    discard: "old",
-
-    // Maximum number of messages in the stream
-    // -1 for unlimited
-    max_msgs: -1,
-
-    // Maximum number of bytes in the stream
-    // -1 for unlimited
-    max_bytes: -1,
-
-    // Maximum age of messages
-    // Examples: "24h", "7d", "1y"
-    max_age: "24h",
-
-    // Maximum message size in bytes
-    // -1 for unlimited
-    max_msg_size: -1,
-
-    // Maximum number of messages per subject
-    // -1 for unlimited
-    max_msgs_per_subject: -1,
-
-    // Maximum number of consumers
-    // -1 for unlimited
-    max_consumers: -1
-}`
-	sap.textArea.SetText(userFriendlyJSON5, false)
+	sap.editor.setValues(newStreamConfigValues())
 }
 
 func (sap *StreamAddPage) setupInputCapture() {
@@ -128,135 +94,88 @@ func (sap *StreamAddPage) setupInputCapture() {
 			return nil
 		}
 		if event.Key() == tcell.KeyEnter && event.Modifiers() == tcell.ModAlt {
-			yamlText := sap.textArea.GetText()
-			
-			// Parse JSON5 into a map
-			var jsonData map[string]interface{}
-			err := json5.Unmarshal([]byte(yamlText), &jsonData)
-			if err != nil {
-				sap.notify("Invalid JSON5 configuration: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// Convert to regular JSON
-			jsonBytes, err := json.Marshal(jsonData)
-			if err != nil {
-				sap.notify("Error converting configuration: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// First unmarshal into an intermediate struct that keeps max_age as string
-			var intermediateConfig struct {
-				Name              string   `json:"name"`
-				Description       string   `json:"description"`
-				Subjects         []string `json:"subjects"`
-				Retention        string   `json:"retention"`
-				MaxConsumers     int      `json:"max_consumers"`
-				MaxMsgs          int64    `json:"max_msgs"`
-				MaxBytes         int64    `json:"max_bytes"`
-				Discard          string   `json:"discard"`
-				MaxAge           string   `json:"max_age"`
-				MaxMsgsPerSubject int64    `json:"max_msgs_per_subject"`
-				MaxMsgSize       int32    `json:"max_msg_size"`
-				Storage          string   `json:"storage"`
-				Replicas         int      `json:"num_replicas"`
-			}
-			err = json.Unmarshal(jsonBytes, &intermediateConfig)
-			if err != nil {
-				sap.notify("Invalid configuration: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// Parse duration string
-			maxAge, err := time.ParseDuration(intermediateConfig.MaxAge)
-			if err != nil {
-				sap.notify("Invalid max_age duration: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// Convert string values to proper NATS types
-			retention, err := parseRetentionPolicy(intermediateConfig.Retention)
-			if err != nil {
-				sap.notify("Invalid retention policy: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			storage, err := parseStorageType(intermediateConfig.Storage)
-			if err != nil {
-				sap.notify("Invalid storage type: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			discard, err := parseDiscardPolicy(intermediateConfig.Discard)
-			if err != nil {
-				sap.notify("Invalid discard policy: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// Create final config
-			config := nats.StreamConfig{
-				Name:              intermediateConfig.Name,
-				Description:       intermediateConfig.Description,
-				Subjects:         intermediateConfig.Subjects,
-				Retention:        retention,
-				MaxConsumers:     intermediateConfig.MaxConsumers,
-				MaxMsgs:          intermediateConfig.MaxMsgs,
-				MaxBytes:         intermediateConfig.MaxBytes,
-				Discard:          discard,
-				MaxAge:           maxAge,
-				MaxMsgsPerSubject: intermediateConfig.MaxMsgsPerSubject,
-				MaxMsgSize:       intermediateConfig.MaxMsgSize,
-				Storage:          storage,
-				Replicas:         intermediateConfig.Replicas,
-			}
-			if err != nil {
-				sap.notify("Invalid YAML configuration: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// Connect to NATS
-			conn := sap.Data.CurrCtx.Conn
-
-			// Get JetStream context
-			js, err := conn.JetStream()
-			if err != nil {
-				sap.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			// Convert to nats.StreamConfig
-			streamConfig := nats.StreamConfig{
-				Name:              config.Name,
-				Description:       config.Description,
-				Subjects:         config.Subjects,
-				Retention:        config.Retention,
-				MaxConsumers:     config.MaxConsumers,
-				MaxMsgs:          config.MaxMsgs,
-				MaxBytes:         config.MaxBytes,
-				Discard:          config.Discard,
-				MaxAge:           config.MaxAge,
-				MaxMsgsPerSubject: config.MaxMsgsPerSubject,
-				MaxMsgSize:       config.MaxMsgSize,
-				Storage:          config.Storage,
-				Replicas:         config.Replicas,
-			}
-
-			var err2 error
-			if sap.isEdit {
-				_, err2 = js.UpdateStream(&streamConfig)
-			} else {
-				_, err2 = js.AddStream(&streamConfig)
-			}
-			if err2 != nil {
-				sap.notify("Failed to create stream: "+err2.Error(), 3*time.Second, "error")
-				return nil
-			}
-
-			sap.notify("Stream created successfully", 3*time.Second, "info")
-			sap.goBack()
+			sap.saveStream()
 			return nil
 		}
-		return event
+		if event.Key() == tcell.KeyCtrlO {
+			sap.editSources()
+			return nil
+		}
+		if event.Key() == tcell.KeyCtrlS {
+			sap.promptSaveTemplate()
+			return nil
+		}
+		return sap.editor.handleKey(event)
+	})
+}
+
+// saveStream sends the editor content as raw JSON so fields that nats.go
+// doesn't know about reach the server unchanged. Updates are reviewed as a
+// diff against the live configuration first.
+func (sap *StreamAddPage) saveStream() {
+	values, err := sap.editor.values()
+	if err != nil {
+		sap.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+	config, _, err := streamConfigFromValues(values)
+	if err != nil {
+		sap.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	conn := sap.Data.CurrCtx.Conn
+	if !sap.isEdit {
+		if err := natsutil.CreateStreamRaw(conn, config); err != nil {
+			sap.notify("Failed to save stream: "+err.Error(), 3*time.Second, "error")
+			return
+		}
+		sap.notify("Stream created successfully", 3*time.Second, "info")
+		sap.goBack()
+		return
+	}
+
+	if name, _ := config["name"].(string); name != sap.streamName {
+		sap.notify("The stream name can't be changed", 3*time.Second, "error")
+		return
+	}
+	liveConfig, err := natsutil.StreamConfigRaw(conn, sap.streamName)
+	if err != nil {
+		sap.notify("Failed to get stream info: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	changes := diffConfigs(liveConfig, config, streamImmutableFields)
+	if len(changes) == 0 {
+		sap.notify("No changes to save", 3*time.Second, "info")
+		return
+	}
+	showConfigDiffModal("Update stream "+sap.streamName, changes, func() {
+		sap.updateStream(config)
+	})
+}
+
+func (sap *StreamAddPage) updateStream(config map[string]interface{}) {
+	if err := natsutil.UpdateStreamRaw(sap.Data.CurrCtx.Conn, config); err != nil {
+		sap.notify("Failed to update stream: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	sap.notify("Stream updated successfully", 3*time.Second, "info")
+	sap.goBack()
+}
+
+// editSources opens the mirror and sources editor for the current editor content
+func (sap *StreamAddPage) editSources() {
+	values, err := sap.editor.values()
+	if err != nil {
+		sap.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	pages.SwitchToPage("streamSourcesPage")
+	_, b := pages.GetFrontPage()
+	b.(*StreamSourcesPage).edit(values, func(values map[string]interface{}) {
+		sap.editor.setValues(values)
+		sap.notify("Mirror and sources updated, press Alt+Enter to save the stream", 3*time.Second, "info")
 	})
 }
 
@@ -269,126 +188,21 @@ func (sap *StreamAddPage) goBack() {
 
 func (sap *StreamAddPage) redraw(ctx *ds.Context) {
 	if !sap.isEdit {
+		values := sap.template
+		if values == nil {
+			values = newStreamConfigValues()
+		}
+		sap.editor.setValues(values)
 		return
 	}
 
-	// Connect to NATS
-	conn := ctx.Conn
-
-	// Get JetStream context
-	js, err := conn.JetStream()
-	if err != nil {
-		sap.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
-		return
-	}
-
-	// Get stream info
-	stream, err := js.StreamInfo(sap.streamName)
+	config, err := natsutil.StreamConfigRaw(ctx.Conn, sap.streamName)
 	if err != nil {
 		sap.notify("Failed to get stream info: "+err.Error(), 3*time.Second, "error")
 		return
 	}
 
-	// Convert the config to our intermediate format
-	config := struct {
-		Name              string   `json:"name"`
-		Description       string   `json:"description"`
-		Subjects         []string `json:"subjects"`
-		Retention        string   `json:"retention"`
-		MaxConsumers     int      `json:"max_consumers"`
-		MaxMsgs          int64    `json:"max_msgs"`
-		MaxBytes         int64    `json:"max_bytes"`
-		Discard          string   `json:"discard"`
-		MaxAge           string   `json:"max_age"`
-		MaxMsgsPerSubject int64    `json:"max_msgs_per_subject"`
-		MaxMsgSize       int32    `json:"max_msg_size"`
-		Storage          string   `json:"storage"`
-		Replicas         int      `json:"num_replicas"`
-	}{
-		Name:              stream.Config.Name,
-		Description:       stream.Config.Description,
-		Subjects:         stream.Config.Subjects,
-		Retention:        retentionPolicyToString(stream.Config.Retention),
-		MaxConsumers:     stream.Config.MaxConsumers,
-		MaxMsgs:          stream.Config.MaxMsgs,
-		MaxBytes:         stream.Config.MaxBytes,
-		Discard:          discardPolicyToString(stream.Config.Discard),
-		MaxAge:           stream.Config.MaxAge.String(),
-		MaxMsgsPerSubject: stream.Config.MaxMsgsPerSubject,
-		MaxMsgSize:       stream.Config.MaxMsgSize,
-		Storage:          storageTypeToString(stream.Config.Storage),
-		Replicas:         stream.Config.Replicas,
-	}
-
-	// Format configuration with comments
-	configWithComments := fmt.Sprintf(`{
-    // Name of the stream (required)
-    name: %q,
-
-    // Description of the stream (optional)
-    description: %q,
-
-    // Subjects that messages can be published to (required)
-    // Examples: ["orders.*", "shipping.>", "customer.orders.*"]
-    subjects: %s,
-
-    // Storage backend (required)
-    // Possible values: "file", "memory"
-    storage: %q,
-
-    // Number of replicas for the stream
-    // Range: 1-5
-    num_replicas: %d,
-
-    // Retention policy (required)
-    // Possible values: "limits", "interest", "workqueue"
-    retention: %q,
-
-    // Discard policy when limits are reached
-    // Possible values: "old", "new"
-    discard: %q,
-
-    // Maximum number of messages in the stream
-    // -1 for unlimited
-    max_msgs: %d,
-
-    // Maximum number of bytes in the stream
-    // -1 for unlimited
-    max_bytes: %d,
-
-    // Maximum age of messages
-    // Examples: "24h", "7d", "1y"
-    max_age: %q,
-
-    // Maximum message size in bytes
-    // -1 for unlimited
-    max_msg_size: %d,
-
-    // Maximum number of messages per subject
-    // -1 for unlimited
-    max_msgs_per_subject: %d,
-
-    // Maximum number of consumers
-    // -1 for unlimited
-    max_consumers: %d
-}`,
-		config.Name,
-		config.Description,
-		prettyPrintSubjects(config.Subjects),
-		config.Storage,
-		config.Replicas,
-		config.Retention,
-		config.Discard,
-		config.MaxMsgs,
-		config.MaxBytes,
-		config.MaxAge,
-		config.MaxMsgSize,
-		config.MaxMsgsPerSubject,
-		config.MaxConsumers,
-	)
-
-	sap.textArea.SetText(configWithComments, true)
-
+	sap.editor.setValues(streamConfigToEditorValues(config))
 }
 
 func (sap *StreamAddPage) notify(message string, duration time.Duration, logLevel string) {
@@ -402,8 +216,6 @@ func (sap *StreamAddPage) notify(message string, duration time.Duration, logLeve
 	}()
 }
 
-
-
 func parseRetentionPolicy(s string) (nats.RetentionPolicy, error) {
 	switch s {
 	case "limits":
@@ -472,22 +284,3 @@ func discardPolicyToString(d nats.DiscardPolicy) string {
 		return "unknown"
 	}
 }
-
-func prettyPrintSubjects(subjects []string) string {
-	if len(subjects) == 0 {
-		return "[]"
-	}
-	if len(subjects) == 1 {
-		return fmt.Sprintf("[\n        %q\n    ]", subjects[0])
-	}
-	var sb strings.Builder
-	sb.WriteString("[\n")
-	for i, subject := range subjects {
-		sb.WriteString(fmt.Sprintf("        %q", subject))
-		if i < len(subjects)-1 {
-			sb.WriteString(",\n")
-		}
-	}
-	sb.WriteString("\n    ]")
-	return sb.String()
-}
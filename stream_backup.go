@@ -0,0 +1,443 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
+)
+
+const (
+	backupMetaFile = "backup.json"
+	backupDataFile = "stream.tar.s2"
+)
+
+// streamBackup is the backup.json written next to the snapshot data
+type streamBackup struct {
+	Stream  string          `json:"stream"`
+	Context string          `json:"context"`
+	Created time.Time       `json:"created"`
+	Size    int64           `json:"size"`
+	SHA256  string          `json:"sha256"`
+	Config  json.RawMessage `json:"config"`
+	State   json.RawMessage `json:"state"`
+	// Consumers is nil in backups written before it was recorded
+	Consumers *bool `json:"consumers,omitempty"`
+}
+
+// hasConsumers reports whether the snapshot includes consumers. Older backups
+// don't record it, for those the consumer count of the stream state decides.
+func (b *streamBackup) hasConsumers() bool {
+	if b.Consumers != nil {
+		return *b.Consumers
+	}
+	var state struct {
+		Consumers int `json:"consumer_count"`
+	}
+	json.Unmarshal(b.State, &state)
+	return state.Consumers > 0
+}
+
+// showBackupDialog asks where to write the backup of the stream
+func (sp *StreamListPage) showBackupDialog(streamName string) {
+	defaultDir := filepath.Join("backups", streamName+"-"+time.Now().Format("20060102-150405"))
+
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle("Backup " + streamName)
+	form.AddInputField("Directory", defaultDir, 0, nil, nil)
+	form.AddCheckbox("Include Consumers", true, nil)
+	form.AddButton("Start", func() {
+		dir := strings.TrimSpace(form.GetFormItemByLabel("Directory").(*tview.InputField).GetText())
+		withConsumers := form.GetFormItemByLabel("Include Consumers").(*tview.Checkbox).IsChecked()
+		if dir == "" {
+			sp.notify("A directory is required", 3*time.Second, "error")
+			return
+		}
+		closeModal("backupModal")
+		go sp.runBackup(streamName, dir, withConsumers)
+	})
+	form.AddButton("Cancel", func() {
+		closeModal("backupModal")
+	})
+	form.SetCancelFunc(func() {
+		closeModal("backupModal")
+	})
+	showModal("backupModal", form, 80, 11)
+}
+
+// progressNotify reports progress from a background goroutine
+func (sp *StreamListPage) progressNotify(message string, logLevel string) {
+	sp.app.QueueUpdateDraw(func() {
+		sp.notify(message, 10*time.Second, logLevel)
+	})
+}
+
+// throttle returns a function that calls fn at most once per interval
+func throttle(interval time.Duration, fn func()) func() {
+	var last time.Time
+	return func() {
+		if time.Since(last) >= interval {
+			last = time.Now()
+			fn()
+		}
+	}
+}
+
+func (sp *StreamListPage) runBackup(streamName, dir string, withConsumers bool) {
+	var received int64
+	report := throttle(500*time.Millisecond, func() {
+		sp.progressNotify(fmt.Sprintf("Backing up %s: %s received", streamName, humanBytes(uint64(received))), "info")
+	})
+	backup, err := backupStream(sp.Data.CurrCtx.Conn, streamName, dir, withConsumers, func(n int64) {
+		received = n
+		report()
+	})
+	if err != nil {
+		logger.Error("Backup of %s failed: %v", streamName, err)
+		sp.progressNotify("Backup failed: "+err.Error(), "error")
+		return
+	}
+	backup.Context = sp.Data.CurrCtx.Name
+	if err := writeBackupMeta(dir, backup); err != nil {
+		sp.progressNotify("Failed to write backup metadata: "+err.Error(), "error")
+		return
+	}
+	logger.Info("Backed up %s to %s (%d bytes, sha256 %s)", streamName, dir, backup.Size, backup.SHA256)
+	sp.progressNotify(fmt.Sprintf("Backed up %s to %s (%s, sha256 %s)", streamName, dir, humanBytes(uint64(backup.Size)), backup.SHA256[:12]), "info")
+}
+
+// backupStream writes the snapshot data to dir and returns the metadata,
+// including the SHA-256 of the data file
+func backupStream(nc *nats.Conn, streamName, dir string, withConsumers bool, progress func(received int64)) (*streamBackup, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	dataPath := filepath.Join(dir, backupDataFile)
+	f, err := os.Create(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	meta, err := natsutil.SnapshotStream(nc, streamName, withConsumers, io.MultiWriter(f, hash), progress)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamBackup{
+		Stream:    streamName,
+		Created:   time.Now().UTC(),
+		Size:      info.Size(),
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		Config:    meta.Config,
+		State:     meta.State,
+		Consumers: &withConsumers,
+	}, nil
+}
+
+func writeBackupMeta(dir string, backup *streamBackup) error {
+	content, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, backupMetaFile), content, 0644)
+}
+
+// readBackup loads backup.json and verifies the checksum of the data file
+func readBackup(dir string) (*streamBackup, error) {
+	content, err := os.ReadFile(filepath.Join(dir, backupMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	var backup streamBackup
+	if err := json.Unmarshal(content, &backup); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", backupMetaFile, err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, backupDataFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, err
+	}
+	if size != backup.Size || hex.EncodeToString(hash.Sum(nil)) != backup.SHA256 {
+		return nil, fmt.Errorf("checksum mismatch, %s is damaged or incomplete", backupDataFile)
+	}
+	return &backup, nil
+}
+
+// showRestoreDialog asks for the backup directory, the target context and
+// an optional new stream name
+func (sp *StreamListPage) showRestoreDialog() {
+	contextNames := []string{sp.Data.CurrCtx.Name}
+	for _, ctx := range sp.Data.Contexts {
+		if ctx.Name != sp.Data.CurrCtx.Name {
+			contextNames = append(contextNames, ctx.Name)
+		}
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle("Restore Stream")
+	form.AddInputField("Backup Directory", "", 0, nil, nil)
+	form.AddDropDown("Target Context", contextNames, 0, nil)
+	form.AddInputField("New Name (optional)", "", 0, nil, nil)
+	form.AddTextView("", "A new name restores the stream under its original name and subjects first, then copies it into the new stream and deletes the original.", 0, 2, false, false)
+	form.AddButton("Start", func() {
+		dir := strings.TrimSpace(form.GetFormItemByLabel("Backup Directory").(*tview.InputField).GetText())
+		_, ctxName := form.GetFormItemByLabel("Target Context").(*tview.DropDown).GetCurrentOption()
+		newName := strings.TrimSpace(form.GetFormItemByLabel("New Name (optional)").(*tview.InputField).GetText())
+		if dir == "" {
+			sp.notify("A backup directory is required", 3*time.Second, "error")
+			return
+		}
+		if strings.ContainsAny(newName, " .*>") {
+			sp.notify("Invalid stream name: "+newName, 3*time.Second, "error")
+			return
+		}
+		closeModal("restoreModal")
+		go sp.runRestore(dir, ctxName, newName)
+	})
+	form.AddButton("Cancel", func() {
+		closeModal("restoreModal")
+	})
+	form.SetCancelFunc(func() {
+		closeModal("restoreModal")
+	})
+	showModal("restoreModal", form, 80, 16)
+}
+
+// contextConn returns a connection to the named context. The returned close
+// function only closes connections opened here.
+func contextConn(name string) (*nats.Conn, func(), error) {
+	if name == data.CurrCtx.Name && data.CurrCtx.Conn != nil {
+		return data.CurrCtx.Conn, func() {}, nil
+	}
+	for _, ctx := range data.Contexts {
+		if ctx.Name == name {
+			conn, err := natsutil.Connect(&ctx.CtxData)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn, conn.Close, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("context %s not found", name)
+}
+
+func (sp *StreamListPage) runRestore(dir, ctxName, newName string) {
+	backup, err := readBackup(dir)
+	if err != nil {
+		sp.progressNotify("Restore failed: "+err.Error(), "error")
+		return
+	}
+	rename := newName != "" && newName != backup.Stream
+	if rename && backup.hasConsumers() {
+		sp.progressNotify("The backup includes consumers, which can't be carried over to a new name. Back up without consumers to restore under a new name", "error")
+		return
+	}
+	conn, closeConn, err := contextConn(ctxName)
+	if err != nil {
+		sp.progressNotify("Failed to connect to "+ctxName+": "+err.Error(), "error")
+		return
+	}
+	defer closeConn()
+
+	// Snapshots always restore under their original name first
+	if err := checkRestoreNames(conn, backup, newName, rename); err != nil {
+		sp.progressNotify(fmt.Sprintf("Can't restore into %s: %v", ctxName, err), "error")
+		return
+	}
+
+	f, err := os.Open(filepath.Join(dir, backupDataFile))
+	if err != nil {
+		sp.progressNotify("Restore failed: "+err.Error(), "error")
+		return
+	}
+	defer f.Close()
+
+	meta := &natsutil.SnapshotMeta{Config: backup.Config, State: backup.State}
+	var sent int64
+	report := throttle(500*time.Millisecond, func() {
+		sp.progressNotify(fmt.Sprintf("Restoring %s into %s: %d%%", backup.Stream, ctxName, sent*100/max(backup.Size, 1)), "info")
+	})
+	err = natsutil.RestoreStream(conn, meta, f, func(n int64) {
+		sent = n
+		report()
+	})
+	if err != nil {
+		logger.Error("Restore of %s into %s failed: %v", backup.Stream, ctxName, err)
+		sp.progressNotify("Restore failed: "+err.Error(), "error")
+		return
+	}
+
+	finalName := backup.Stream
+	if rename {
+		// Snapshots are bound to the stream name, a rename copies the
+		// restored stream into a new one
+		sp.progressNotify(fmt.Sprintf("Restored %s, copying it to %s", backup.Stream, newName), "info")
+		if err := renameStreamByCopy(conn, backup.Stream, newName, func(copied, total uint64) {
+			sp.progressNotify(fmt.Sprintf("Copying %s to %s: %d of %d messages", backup.Stream, newName, copied, total), "info")
+		}); err != nil {
+			logger.Error("Rename of %s to %s in %s failed: %v", backup.Stream, newName, ctxName, err)
+			sp.progressNotify("Rename failed: "+err.Error(), "error")
+			return
+		}
+		finalName = newName
+	}
+
+	logger.Info("Restored %s from %s into %s as %s", backup.Stream, dir, ctxName, finalName)
+	sp.app.QueueUpdateDraw(func() {
+		sp.notify(fmt.Sprintf("Restored %s into %s as %s", backup.Stream, ctxName, finalName), 10*time.Second, "info")
+		if ctxName == sp.Data.CurrCtx.Name {
+			sp.redraw(&sp.Data.CurrCtx)
+		}
+	})
+}
+
+// checkRestoreNames makes sure the streams a restore creates don't exist yet.
+// A rename also needs the subjects of the original stream to be free, as the
+// stream is restored with them before it is copied.
+func checkRestoreNames(nc *nats.Conn, backup *streamBackup, newName string, rename bool) error {
+	original := backup.Stream
+	names := []string{original}
+	if rename {
+		names = append(names, newName)
+	}
+	for _, name := range names {
+		_, err := natsutil.StreamInfoRaw(nc, name)
+		if err == nil {
+			if rename && name == original {
+				return fmt.Errorf("stream %s already exists. A restore under a new name goes through the original name, delete or rename %s first", name, name)
+			}
+			return fmt.Errorf("stream %s already exists", name)
+		}
+		if !natsutil.IsNotFound(err) {
+			return err
+		}
+	}
+	if !rename {
+		return nil
+	}
+
+	var config struct {
+		Subjects []string `json:"subjects"`
+	}
+	if err := json.Unmarshal(backup.Config, &config); err != nil {
+		return err
+	}
+	for _, subject := range config.Subjects {
+		streams, err := natsutil.StreamsWithSubject(nc, subject)
+		if err != nil {
+			return err
+		}
+		if len(streams) > 0 {
+			return fmt.Errorf("subject %s is used by %s. A restore under a new name goes through the original subjects", subject, strings.Join(streams, ", "))
+		}
+	}
+	return nil
+}
+
+// renameStreamByCopy moves the messages of stream from into a new stream
+// named to by sourcing, then deletes from and gives to its subjects.
+// Sequence numbers start over and consumers aren't carried over. When the
+// copy fails the new stream is deleted again and from is left as it was.
+func renameStreamByCopy(nc *nats.Conn, from, to string, progress func(copied, total uint64)) error {
+	config, err := natsutil.StreamConfigRaw(nc, from)
+	if err != nil {
+		return err
+	}
+	if config["mirror"] != nil || config["sources"] != nil {
+		return fmt.Errorf("streams with a mirror or sources can't be renamed")
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return err
+	}
+	fromInfo, err := js.StreamInfo(from)
+	if err != nil {
+		return err
+	}
+
+	subjects := config["subjects"]
+	copyConfig := copyConfigMap(config)
+	copyConfig["name"] = to
+	delete(copyConfig, "subjects")
+	copyConfig["sources"] = []interface{}{map[string]interface{}{"name": from}}
+	if err := natsutil.CreateStreamRaw(nc, copyConfig); err != nil {
+		return fmt.Errorf("%v, the stream was restored as %s", err, from)
+	}
+
+	rollback := func(err error) error {
+		if delErr := js.DeleteStream(to); delErr != nil {
+			return fmt.Errorf("%v, and deleting %s failed: %v", err, to, delErr)
+		}
+		return fmt.Errorf("%v, the stream was restored as %s", err, from)
+	}
+	if err := waitForSourcedMsgs(js, to, fromInfo.State.Msgs, progress); err != nil {
+		return rollback(err)
+	}
+	if err := js.DeleteStream(from); err != nil {
+		return rollback(err)
+	}
+	copyConfig["subjects"] = subjects
+	delete(copyConfig, "sources")
+	if err := natsutil.UpdateStreamRaw(nc, copyConfig); err != nil {
+		return fmt.Errorf("%s holds the messages of %s but couldn't take its subjects: %v", to, from, err)
+	}
+	return nil
+}
+
+// waitForSourcedMsgs waits until the stream holds total messages and its
+// sources have no lag
+func waitForSourcedMsgs(js nats.JetStreamContext, stream string, total uint64, progress func(copied, total uint64)) error {
+	lastChange := time.Now()
+	var lastMsgs uint64
+	for {
+		info, err := js.StreamInfo(stream)
+		if err != nil {
+			return err
+		}
+		lag := uint64(0)
+		for _, source := range info.Sources {
+			lag += source.Lag
+		}
+		if progress != nil {
+			progress(info.State.Msgs, total)
+		}
+		if info.State.Msgs >= total && lag == 0 {
+			return nil
+		}
+		if info.State.Msgs != lastMsgs {
+			lastMsgs = info.State.Msgs
+			lastChange = time.Now()
+		} else if time.Since(lastChange) > time.Minute {
+			return fmt.Errorf("copy stalled at %d of %d messages", info.State.Msgs, total)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
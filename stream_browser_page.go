@@ -0,0 +1,499 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
+)
+
+const streamBrowserPageSize = 50
+
+// StreamBrowserPage pages through the messages stored in a stream and lets
+// single messages be inspected, deleted or securely erased
+type StreamBrowserPage struct {
+	*tview.Flex
+	Data        *ds.Data
+	app         *tview.Application
+	filterInput *tview.InputField
+	msgTable    *tview.Table
+	tableBox    *tview.Flex
+	detailView  *tview.TextView
+	footerTxt   *tview.TextView
+	streamName  string
+	denyDelete  bool
+	msgs        []*natsutil.StoredMsg
+	selected    map[uint64]bool
+	pageStarts  []uint64 // first sequence of each page visited, for paging back
+	nextSeq     uint64   // where the next page starts, 0 when at the end
+	returnPage  string
+	loadGen     int // bumped for every page load so stale results are dropped
+}
+
+func NewStreamBrowserPage(app *tview.Application, data *ds.Data) *StreamBrowserPage {
+	sbp := &StreamBrowserPage{
+		Flex:       tview.NewFlex().SetDirection(tview.FlexRow),
+		app:        app,
+		Data:       data,
+		selected:   make(map[uint64]bool),
+		returnPage: "streamListPage",
+	}
+	sbp.setupUI()
+	sbp.setupInputCapture()
+	return sbp
+}
+
+func (sbp *StreamBrowserPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back\n[/] Filter Subject", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[n/PgDn] Next Page\n[p/PgUp] Previous Page", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[g] Go to Sequence\n[r] Reload", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Space] Select\n[a] Select Page", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[x] Delete\n[X] Secure Erase", tcell.ColorWhite), 0, 1, false)
+	sbp.AddItem(headerRow, 4, 0, false)
+
+	// Subject filter
+	sbp.filterInput = tview.NewInputField().
+		SetLabel("Filter Subject: ").
+		SetPlaceholder(">").
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	sbp.filterInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			sbp.loadFrom(1)
+		}
+		sbp.app.SetFocus(sbp.msgTable)
+	})
+	sbp.filterInput.SetBorderPadding(0, 0, 1, 1)
+	sbp.AddItem(sbp.filterInput, 1, 0, false)
+
+	// Message table
+	sbp.tableBox = tview.NewFlex()
+	sbp.tableBox.SetBorder(true)
+	sbp.tableBox.SetBorderPadding(0, 0, 1, 1)
+	sbp.msgTable = tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	sbp.msgTable.SetSelectionChangedFunc(func(row, column int) {
+		sbp.showDetail()
+	})
+	sbp.tableBox.AddItem(sbp.msgTable, 0, 1, true)
+	sbp.AddItem(sbp.tableBox, 0, 3, true)
+
+	// Message inspector
+	sbp.detailView = tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
+	sbp.detailView.SetBorder(true)
+	sbp.detailView.SetTitle("Message")
+	sbp.detailView.SetBorderPadding(0, 0, 1, 1)
+	sbp.AddItem(sbp.detailView, 0, 2, false)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	sbp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(sbp.footerTxt, 0, 1, false)
+	sbp.AddItem(footer, 3, 0, false)
+
+	sbp.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (sbp *StreamBrowserPage) setupInputCapture() {
+	sbp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if sbp.filterInput.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEsc:
+			sbp.goBack()
+			return nil
+		case tcell.KeyPgDn:
+			sbp.nextPage()
+			return nil
+		case tcell.KeyPgUp:
+			sbp.previousPage()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case '/':
+				sbp.app.SetFocus(sbp.filterInput)
+				return nil
+			case 'n':
+				sbp.nextPage()
+				return nil
+			case 'p':
+				sbp.previousPage()
+				return nil
+			case 'r':
+				sbp.reload()
+				return nil
+			case 'g':
+				showPromptModal("Go to Sequence", "Sequence: ", "", func(text string) {
+					seq, err := strconv.ParseUint(strings.TrimSpace(text), 10, 64)
+					if err != nil || seq == 0 {
+						sbp.notify("Invalid sequence: "+text, 3*time.Second, "error")
+						return
+					}
+					sbp.loadFrom(seq)
+				})
+				return nil
+			case ' ':
+				sbp.toggleSelected()
+				return nil
+			case 'a':
+				sbp.toggleSelectPage()
+				return nil
+			case 'x':
+				sbp.deleteMessages(false)
+				return nil
+			case 'X':
+				sbp.deleteMessages(true)
+				return nil
+			}
+		}
+		return event
+	})
+}
+
+// open shows the messages of the stream, optionally limited to a subject filter
+func (sbp *StreamBrowserPage) open(ctx *ds.Context, streamName string, filter string) {
+	sbp.streamName = streamName
+	sbp.filterInput.SetText(filter)
+	sbp.redraw(ctx)
+}
+
+func (sbp *StreamBrowserPage) redraw(ctx *ds.Context) {
+	sbp.selected = make(map[uint64]bool)
+	sbp.denyDelete = false
+
+	js, err := ctx.Conn.JetStream()
+	if err != nil {
+		sbp.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	info, err := js.StreamInfo(sbp.streamName)
+	if err != nil {
+		sbp.notify("Failed to get stream info: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	sbp.denyDelete = info.Config.DenyDelete
+	if sbp.denyDelete {
+		sbp.notify("Deleting is disabled: the stream has deny_delete set", 5*time.Second, "warn")
+	}
+
+	start := info.State.FirstSeq
+	if start == 0 {
+		start = 1
+	}
+	sbp.loadFrom(start)
+	sbp.app.SetFocus(sbp.msgTable)
+}
+
+// loadFrom starts a new page history at seq
+func (sbp *StreamBrowserPage) loadFrom(seq uint64) {
+	sbp.pageStarts = []uint64{seq}
+	sbp.loadPage(seq)
+}
+
+func (sbp *StreamBrowserPage) reload() {
+	if len(sbp.pageStarts) == 0 {
+		sbp.loadFrom(1)
+		return
+	}
+	sbp.loadPage(sbp.pageStarts[len(sbp.pageStarts)-1])
+}
+
+func (sbp *StreamBrowserPage) nextPage() {
+	if sbp.nextSeq == 0 {
+		sbp.notify("No more messages", 2*time.Second, "info")
+		return
+	}
+	sbp.pageStarts = append(sbp.pageStarts, sbp.nextSeq)
+	sbp.loadPage(sbp.nextSeq)
+}
+
+func (sbp *StreamBrowserPage) previousPage() {
+	if len(sbp.pageStarts) < 2 {
+		sbp.notify("Already at the first page", 2*time.Second, "info")
+		return
+	}
+	sbp.pageStarts = sbp.pageStarts[:len(sbp.pageStarts)-1]
+	sbp.loadPage(sbp.pageStarts[len(sbp.pageStarts)-1])
+}
+
+// loadPage fetches up to a page of messages starting at seq in the background
+func (sbp *StreamBrowserPage) loadPage(seq uint64) {
+	filter := strings.TrimSpace(sbp.filterInput.GetText())
+	if filter != "" && !isValidSubject(filter) {
+		sbp.notify("Invalid filter subject: "+filter, 3*time.Second, "error")
+		return
+	}
+
+	sbp.loadGen++
+	gen := sbp.loadGen
+	conn := sbp.Data.CurrCtx.Conn
+	streamName := sbp.streamName
+	sbp.tableBox.SetTitle(fmt.Sprintf("Messages of %s (loading from seq %d...)", streamName, seq))
+
+	go func() {
+		msgs := make([]*natsutil.StoredMsg, 0, streamBrowserPageSize)
+		var loadErr error
+		for len(msgs) < streamBrowserPageSize {
+			msg, err := natsutil.GetNextMsg(conn, streamName, seq, filter)
+			if natsutil.IsNotFound(err) {
+				break
+			}
+			if err != nil {
+				loadErr = err
+				break
+			}
+			msgs = append(msgs, msg)
+			seq = msg.Sequence + 1
+		}
+
+		sbp.app.QueueUpdateDraw(func() {
+			if gen != sbp.loadGen {
+				return
+			}
+			if loadErr != nil {
+				sbp.notify("Failed to get message: "+loadErr.Error(), 3*time.Second, "error")
+			}
+			sbp.msgs = msgs
+			sbp.nextSeq = 0
+			if len(msgs) == streamBrowserPageSize {
+				sbp.nextSeq = seq
+			}
+			sbp.renderTable()
+		})
+	}()
+}
+
+func (sbp *StreamBrowserPage) renderTable() {
+	sbp.msgTable.Clear()
+	for c, title := range []string{"", "Seq", "Time", "Subject", "Size", "Payload"} {
+		sbp.msgTable.SetCell(0, c, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+	for r, msg := range sbp.msgs {
+		mark := " "
+		if sbp.selected[msg.Sequence] {
+			mark = "*"
+		}
+		sbp.msgTable.SetCell(r+1, 0, tview.NewTableCell(mark).SetTextColor(tcell.ColorRed))
+		sbp.msgTable.SetCell(r+1, 1, tview.NewTableCell(strconv.FormatUint(msg.Sequence, 10)).SetAlign(tview.AlignRight))
+		sbp.msgTable.SetCell(r+1, 2, tview.NewTableCell(msg.Time.Local().Format("2006-01-02 15:04:05")))
+		sbp.msgTable.SetCell(r+1, 3, tview.NewTableCell(tview.Escape(msg.Subject)).SetMaxWidth(40))
+		sbp.msgTable.SetCell(r+1, 4, tview.NewTableCell(humanBytes(uint64(len(msg.Data)))).SetAlign(tview.AlignRight))
+		sbp.msgTable.SetCell(r+1, 5, tview.NewTableCell(tview.Escape(previewText(string(msg.Data), 80))).SetExpansion(1))
+	}
+
+	title := fmt.Sprintf("Messages of %s", sbp.streamName)
+	if len(sbp.msgs) > 0 {
+		title += fmt.Sprintf(" (seq %d-%d", sbp.msgs[0].Sequence, sbp.msgs[len(sbp.msgs)-1].Sequence)
+		if len(sbp.selected) > 0 {
+			title += fmt.Sprintf(", %d selected", len(sbp.selected))
+		}
+		title += ")"
+	} else {
+		title += " (no messages)"
+	}
+	if sbp.denyDelete {
+		title += " [deny_delete]"
+	}
+	sbp.tableBox.SetTitle(title)
+
+	if len(sbp.msgs) > 0 {
+		row, _ := sbp.msgTable.GetSelection()
+		if row < 1 || row > len(sbp.msgs) {
+			row = 1
+		}
+		sbp.msgTable.Select(row, 0)
+	}
+	sbp.showDetail()
+}
+
+func (sbp *StreamBrowserPage) currentMsg() *natsutil.StoredMsg {
+	row, _ := sbp.msgTable.GetSelection()
+	if row < 1 || row > len(sbp.msgs) {
+		return nil
+	}
+	return sbp.msgs[row-1]
+}
+
+// showDetail renders headers and payload of the message under the cursor
+func (sbp *StreamBrowserPage) showDetail() {
+	msg := sbp.currentMsg()
+	if msg == nil {
+		sbp.detailView.SetText("")
+		return
+	}
+	sbp.detailView.SetText(formatStoredMsg(msg))
+	sbp.detailView.ScrollToBeginning()
+}
+
+// formatStoredMsg renders the sequence, subject, time, headers and payload
+func formatStoredMsg(msg *natsutil.StoredMsg) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[yellow]Sequence:[-] %d\n", msg.Sequence))
+	sb.WriteString("[yellow]Subject:[-]  " + tview.Escape(msg.Subject) + "\n")
+	sb.WriteString("[yellow]Time:[-]     " + msg.Time.Local().Format(time.RFC3339Nano) + "\n")
+	if len(msg.Header) > 0 {
+		header := parseRawHeaders(msg.Header)
+		keys := make([]string, 0, len(header))
+		for k := range header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("[yellow]Headers:[-]\n")
+		for _, k := range keys {
+			for _, v := range header[k] {
+				sb.WriteString("  " + tview.Escape(k+": "+v) + "\n")
+			}
+		}
+	}
+	sb.WriteString("\n" + tview.Escape(string(msg.Data)))
+	return sb.String()
+}
+
+func (sbp *StreamBrowserPage) toggleSelected() {
+	msg := sbp.currentMsg()
+	if msg == nil {
+		return
+	}
+	if sbp.selected[msg.Sequence] {
+		delete(sbp.selected, msg.Sequence)
+	} else {
+		sbp.selected[msg.Sequence] = true
+	}
+	row, _ := sbp.msgTable.GetSelection()
+	sbp.renderTable()
+	if row < len(sbp.msgs) {
+		sbp.msgTable.Select(row+1, 0)
+	}
+}
+
+// toggleSelectPage selects every message of the page, or clears the
+// selection when all of them are selected already
+func (sbp *StreamBrowserPage) toggleSelectPage() {
+	all := true
+	for _, msg := range sbp.msgs {
+		if !sbp.selected[msg.Sequence] {
+			all = false
+			break
+		}
+	}
+	for _, msg := range sbp.msgs {
+		if all {
+			delete(sbp.selected, msg.Sequence)
+		} else {
+			sbp.selected[msg.Sequence] = true
+		}
+	}
+	sbp.renderTable()
+}
+
+// deleteMessages removes the selected messages, or the one under the cursor
+// when nothing is selected. A secure erase overwrites the message data.
+func (sbp *StreamBrowserPage) deleteMessages(secure bool) {
+	if sbp.denyDelete {
+		sbp.notify("Can't delete messages: the stream has deny_delete set, edit the stream to allow deletes", 5*time.Second, "error")
+		return
+	}
+
+	seqs := make([]uint64, 0, len(sbp.selected))
+	for seq := range sbp.selected {
+		seqs = append(seqs, seq)
+	}
+	if len(seqs) == 0 {
+		msg := sbp.currentMsg()
+		if msg == nil {
+			sbp.notify("No message selected", 3*time.Second, "error")
+			return
+		}
+		seqs = append(seqs, msg.Sequence)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	action := "Delete"
+	if secure {
+		action = "Securely erase"
+	}
+	shown := make([]string, 0, len(seqs))
+	for i, seq := range seqs {
+		if i == 20 {
+			shown = append(shown, fmt.Sprintf("... and %d more", len(seqs)-i))
+			break
+		}
+		shown = append(shown, strconv.FormatUint(seq, 10))
+	}
+	text := fmt.Sprintf("[red::b]%s %d message(s) from %s?[-::-]\n\nSequences: %s\n",
+		action, len(seqs), tview.Escape(sbp.streamName), strings.Join(shown, ", "))
+	if secure {
+		text += "\nThe message data is overwritten before removal, this can't be undone.\n"
+	}
+
+	showConfirmModal(action+" messages", text, func() {
+		sbp.executeDelete(seqs, secure)
+	})
+}
+
+func (sbp *StreamBrowserPage) executeDelete(seqs []uint64, secure bool) {
+	js, err := sbp.Data.CurrCtx.Conn.JetStream()
+	if err != nil {
+		sbp.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	failed := 0
+	var lastErr error
+	for _, seq := range seqs {
+		if secure {
+			err = js.SecureDeleteMsg(sbp.streamName, seq)
+		} else {
+			err = js.DeleteMsg(sbp.streamName, seq)
+		}
+		if err != nil {
+			logger.Error("Failed to delete message %d from %s: %v", seq, sbp.streamName, err)
+			failed++
+			lastErr = err
+			continue
+		}
+		delete(sbp.selected, seq)
+	}
+
+	if failed > 0 {
+		sbp.notify(fmt.Sprintf("Deleted %d of %d messages, last error: %v", len(seqs)-failed, len(seqs), lastErr), 5*time.Second, "error")
+	} else {
+		sbp.notify(fmt.Sprintf("Deleted %d message(s)", len(seqs)), 3*time.Second, "info")
+	}
+	sbp.reload()
+}
+
+func (sbp *StreamBrowserPage) goBack() {
+	pages.SwitchToPage(sbp.returnPage)
+	_, b := pages.GetFrontPage()
+	if sp, ok := b.(*StreamListPage); ok {
+		sp.redraw(&sbp.Data.CurrCtx)
+	}
+	sbp.app.SetFocus(b)
+}
+
+func (sbp *StreamBrowserPage) notify(message string, duration time.Duration, logLevel string) {
+	sbp.footerTxt.SetText(message)
+	sbp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		sbp.footerTxt.SetText("")
+		sbp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// configField describes a documented key of a config editor
+type configField struct {
+	key     string
+	comment []string
+	value   interface{} // default used for new configurations
+}
+
+// streamConfigFields lists every nats.StreamConfig field in editor order
+var streamConfigFields = []configField{
+	{"name", []string{"Name of the stream (required)"}, "my_stream"},
+	{"description", []string{"Description of the stream (optional)"}, "My Stream Description"},
+	{"subjects", []string{"Subjects that messages can be published to (required unless mirroring)", `Examples: ["orders.*", "shipping.>", "customer.orders.*"]`}, []interface{}{"my.subject.>"}},
+	{"storage", []string{"Storage backend (required)", `Possible values: "file", "memory"`}, "file"},
+	{"num_replicas", []string{"Number of replicas for the stream", "Range: 1-5"}, 1},
+	{"retention", []string{"Retention policy (required)", `Possible values: "limits", "interest", "workqueue"`}, "limits"},
+	{"discard", []string{"Discard policy when limits are reached", `Possible values: "old", "new"`}, "old"},
+	{"discard_new_per_subject", []string{"Apply the \"new\" discard policy per subject", "Requires discard \"new\" and max_msgs_per_subject"}, false},
+	{"max_msgs", []string{"Maximum number of messages in the stream", "-1 for unlimited"}, -1},
+	{"max_bytes", []string{"Maximum number of bytes in the stream", "-1 for unlimited"}, -1},
+	{"max_age", []string{"Maximum age of messages", `Examples: "24h", "7d", "1y", "0s" for unlimited`}, "24h"},
+	{"max_msg_size", []string{"Maximum message size in bytes", "-1 for unlimited"}, -1},
+	{"max_msgs_per_subject", []string{"Maximum number of messages per subject", "-1 for unlimited"}, -1},
+	{"max_consumers", []string{"Maximum number of consumers", "-1 for unlimited"}, -1},
+	{"duplicate_window", []string{"Window to track duplicate messages by Nats-Msg-Id", `Examples: "2m", "1h"`}, "2m"},
+	{"no_ack", []string{"Disable publish acknowledgements"}, false},
+	{"compression", []string{"Storage compression", `Possible values: "none", "s2"`}, "none"},
+	{"first_seq", []string{"Initial sequence number of the first message"}, 0},
+	{"allow_rollup_hdrs", []string{"Allow the Nats-Rollup header to purge a subject or the stream"}, false},
+	{"allow_direct", []string{"Allow direct get of individual messages"}, false},
+	{"mirror_direct", []string{"Allow direct get from the origin stream of a mirror"}, false},
+	{"deny_delete", []string{"Forbid deleting individual messages via the API"}, false},
+	{"deny_purge", []string{"Forbid purging the stream via the API"}, false},
+	{"sealed", []string{"Seal the stream, it can't be unsealed (update only)"}, false},
+	{"subject_transform", []string{"Transform subjects of stored messages", `Example: { src: "orders.*", dest: "archive.orders.{{wildcard(1)}}" }`}, nil},
+	{"republish", []string{"Republish stored messages to another subject", `Example: { src: ">", dest: "repub.>", headers_only: false }`}, nil},
+	{"placement", []string{"Cluster and tags to place the stream on", `Example: { cluster: "east", tags: ["ssd"] }`}, nil},
+	{"mirror", []string{"Stream to mirror, subjects must be empty when set"}, nil},
+	{"sources", []string{"Streams to source messages from"}, nil},
+	{"consumer_limits", []string{"Defaults and limits applied to consumers", `Example: { inactive_threshold: "1h", max_ack_pending: 1000 }`}, map[string]interface{}{}},
+	{"metadata", []string{"Application defined key/value pairs"}, map[string]interface{}{}},
+}
+
+// duration fields are edited as strings like "24h" and sent as nanoseconds
+var streamDurationFields = []string{"max_age", "duplicate_window"}
+var consumerLimitsDurationFields = []string{"inactive_threshold"}
+var consumerDurationFields = []string{"ack_wait", "idle_heartbeat", "max_expires", "inactive_threshold"}
+
+var streamEnumFields = map[string]bool{"retention": true, "storage": true, "discard": true, "compression": true}
+
+// newStreamConfigValues returns the editor values for a new stream
+func newStreamConfigValues() map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, f := range streamConfigFields {
+		values[f.key] = f.value
+	}
+	return values
+}
+
+// streamConfigToEditorValues converts a server config map into editor values.
+// Fields the server omitted are shown with their empty value so every option
+// is visible while editing.
+func streamConfigToEditorValues(config map[string]interface{}) map[string]interface{} {
+	values := copyConfigMap(config)
+	for _, f := range streamConfigFields {
+		if _, ok := values[f.key]; ok {
+			continue
+		}
+		if streamEnumFields[f.key] {
+			// An empty enum isn't valid, the default is what the server assumes
+			values[f.key] = f.value
+		} else {
+			values[f.key] = zeroConfigValue(f.value)
+		}
+	}
+	nanosToDurationStrings(values, streamDurationFields)
+	if limits, ok := values["consumer_limits"].(map[string]interface{}); ok {
+		nanosToDurationStrings(limits, consumerLimitsDurationFields)
+	}
+	return values
+}
+
+// withStreamDefaults fills the fields a server config map leaves out with
+// the values that make the server use its defaults, so that sending it
+// replaces the whole configuration
+func withStreamDefaults(config map[string]interface{}) map[string]interface{} {
+	values := copyConfigMap(config)
+	for _, f := range streamConfigFields {
+		if _, ok := values[f.key]; ok {
+			continue
+		}
+		switch {
+		case streamEnumFields[f.key]:
+			values[f.key] = f.value
+		case containsString(streamDurationFields, f.key):
+			values[f.key] = 0
+		default:
+			values[f.key] = zeroConfigValue(f.value)
+		}
+	}
+	return values
+}
+
+// streamConfigFromValues converts editor values into the server config map
+func streamConfigFromValues(values map[string]interface{}) (map[string]interface{}, *nats.StreamConfig, error) {
+	config := copyConfigMap(values)
+	if err := durationStringsToNanos(config, streamDurationFields); err != nil {
+		return nil, nil, err
+	}
+	if limits, ok := config["consumer_limits"].(map[string]interface{}); ok {
+		if err := durationStringsToNanos(limits, consumerLimitsDurationFields); err != nil {
+			return nil, nil, fmt.Errorf("consumer_limits: %v", err)
+		}
+	}
+
+	// Check the enums up front for a clearer error than the JSON decoder gives
+	enumParsers := map[string]func(string) error{
+		"retention":   func(s string) error { _, err := parseRetentionPolicy(s); return err },
+		"storage":     func(s string) error { _, err := parseStorageType(s); return err },
+		"discard":     func(s string) error { _, err := parseDiscardPolicy(s); return err },
+		"compression": func(s string) error { _, err := parseCompression(s); return err },
+	}
+	for key, parse := range enumParsers {
+		if v, ok := config[key]; ok && v != nil {
+			s, isString := v.(string)
+			if !isString {
+				return nil, nil, fmt.Errorf("invalid %s: expected a string", key)
+			}
+			if err := parse(s); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s: %v", key, err)
+			}
+		}
+	}
+
+	jsonBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error converting configuration: %v", err)
+	}
+	var streamConfig nats.StreamConfig
+	if err := json.Unmarshal(jsonBytes, &streamConfig); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	if streamConfig.Name == "" {
+		return nil, nil, fmt.Errorf("stream name is required")
+	}
+	return config, &streamConfig, nil
+}
+
+// formatConfigJSON5 renders values as commented JSON5. Documented fields come
+// first in their defined order, any other keys are kept at the end.
+func formatConfigJSON5(fields []configField, values map[string]interface{}) string {
+	known := make(map[string]bool)
+	entries := make([]string, 0, len(values))
+	for _, f := range fields {
+		known[f.key] = true
+		v, ok := values[f.key]
+		if !ok {
+			continue
+		}
+		var sb strings.Builder
+		for _, c := range f.comment {
+			sb.WriteString("    // " + c + "\n")
+		}
+		sb.WriteString("    " + f.key + ": " + formatJSON5Value(v))
+		entries = append(entries, sb.String())
+	}
+
+	extra := make([]string, 0)
+	for k := range values {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for i, k := range extra {
+		entry := "    " + k + ": " + formatJSON5Value(values[k])
+		if i == 0 {
+			entry = "    // Other fields, kept as they are\n" + entry
+		}
+		entries = append(entries, entry)
+	}
+
+	return "{\n" + strings.Join(entries, ",\n\n") + "\n}"
+}
+
+func formatJSON5Value(v interface{}) string {
+	b, err := json.MarshalIndent(v, "    ", "    ")
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
+
+func copyConfigMap(m map[string]interface{}) map[string]interface{} {
+	b, _ := json.Marshal(m)
+	var c map[string]interface{}
+	json.Unmarshal(b, &c)
+	if c == nil {
+		c = make(map[string]interface{})
+	}
+	return c
+}
+
+func zeroConfigValue(v interface{}) interface{} {
+	switch v.(type) {
+	case bool:
+		return false
+	case int:
+		return 0
+	case string:
+		return ""
+	case map[string]interface{}:
+		return map[string]interface{}{}
+	}
+	return nil
+}
+
+func durationStringsToNanos(m map[string]interface{}, keys []string) error {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok {
+			d, err := parseHumanDuration(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s duration: %v", key, err)
+			}
+			m[key] = d.Nanoseconds()
+		}
+	}
+	return nil
+}
+
+func nanosToDurationStrings(m map[string]interface{}, keys []string) {
+	for _, key := range keys {
+		if n, ok := m[key].(float64); ok {
+			m[key] = formatHumanDuration(time.Duration(int64(n)))
+		}
+	}
+}
+
+func parseCompression(s string) (nats.StoreCompression, error) {
+	switch s {
+	case "none", "":
+		return nats.NoCompression, nil
+	case "s2":
+		return nats.S2Compression, nil
+	default:
+		return 0, fmt.Errorf("unknown compression: %s", s)
+	}
+}
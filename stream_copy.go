@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
+)
+
+const (
+	copyMsgsNone      = "Don't copy messages"
+	copyMsgsSource    = "Source from the original stream"
+	copyMsgsRepublish = "Re-publish the messages"
+)
+
+// streamCopyOptions are the choices of the copy dialog
+type streamCopyOptions struct {
+	stream        string
+	targetCtx     string
+	newName       string
+	replicas      int
+	cluster       string
+	tags          []string
+	copyConsumers bool
+	msgsMode      string
+	apiPrefix     string // external API prefix used when sourcing across accounts or domains
+	keepOrigins   bool   // keep the mirror and sources of the original stream
+}
+
+// showCopyDialog asks where and how to copy the stream
+func (sp *StreamListPage) showCopyDialog(streamName string) {
+	contextNames := make([]string, 0, len(sp.Data.Contexts))
+	for _, ctx := range sp.Data.Contexts {
+		if ctx.Name != sp.Data.CurrCtx.Name {
+			contextNames = append(contextNames, ctx.Name)
+		}
+	}
+	if len(contextNames) == 0 {
+		sp.notify("There is no other context to copy to", 3*time.Second, "error")
+		return
+	}
+
+	config, err := natsutil.StreamConfigRaw(sp.Data.CurrCtx.Conn, streamName)
+	if err != nil {
+		sp.notify("Failed to get stream info: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	replicas := 1
+	if n, ok := config["num_replicas"].(float64); ok && n > 0 {
+		replicas = int(n)
+	}
+	cluster, tags := "", ""
+	if placement, ok := config["placement"].(map[string]interface{}); ok {
+		cluster, _ = placement["cluster"].(string)
+		if list, ok := placement["tags"].([]interface{}); ok {
+			parts := make([]string, 0, len(list))
+			for _, t := range list {
+				parts = append(parts, fmt.Sprint(t))
+			}
+			tags = strings.Join(parts, ", ")
+		}
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle("Copy " + streamName + " to another context")
+	form.AddDropDown("Target Context", contextNames, 0, nil)
+	form.AddInputField("Stream Name", streamName, 0, nil, nil)
+	form.AddInputField("Replicas", strconv.Itoa(replicas), 6, tview.InputFieldInteger, nil)
+	form.AddInputField("Placement Cluster", cluster, 0, nil, nil)
+	form.AddInputField("Placement Tags", tags, 0, nil, nil)
+	form.AddCheckbox("Copy Consumers", true, nil)
+	if config["mirror"] != nil || config["sources"] != nil {
+		// They name streams of this context that rarely exist in the target
+		form.AddCheckbox("Keep Mirror/Sources", false, nil)
+	}
+	form.AddDropDown("Messages", []string{copyMsgsNone, copyMsgsSource, copyMsgsRepublish}, 0, nil)
+	form.AddInputField("Source API Prefix", "", 0, nil, nil)
+	form.AddButton("Copy", func() {
+		opts := streamCopyOptions{
+			stream:        streamName,
+			newName:       strings.TrimSpace(form.GetFormItemByLabel("Stream Name").(*tview.InputField).GetText()),
+			cluster:       strings.TrimSpace(form.GetFormItemByLabel("Placement Cluster").(*tview.InputField).GetText()),
+			copyConsumers: form.GetFormItemByLabel("Copy Consumers").(*tview.Checkbox).IsChecked(),
+			apiPrefix:     strings.TrimSpace(form.GetFormItemByLabel("Source API Prefix").(*tview.InputField).GetText()),
+		}
+		_, opts.targetCtx = form.GetFormItemByLabel("Target Context").(*tview.DropDown).GetCurrentOption()
+		_, opts.msgsMode = form.GetFormItemByLabel("Messages").(*tview.DropDown).GetCurrentOption()
+		if keep, ok := form.GetFormItemByLabel("Keep Mirror/Sources").(*tview.Checkbox); ok {
+			opts.keepOrigins = keep.IsChecked()
+		}
+		for _, t := range strings.Split(form.GetFormItemByLabel("Placement Tags").(*tview.InputField).GetText(), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				opts.tags = append(opts.tags, t)
+			}
+		}
+		n, err := strconv.Atoi(form.GetFormItemByLabel("Replicas").(*tview.InputField).GetText())
+		if err != nil || n < 1 || n > 5 {
+			sp.notify("Replicas must be between 1 and 5", 3*time.Second, "error")
+			return
+		}
+		opts.replicas = n
+		if opts.newName == "" || strings.ContainsAny(opts.newName, " .*>") {
+			sp.notify("Invalid stream name: "+opts.newName, 3*time.Second, "error")
+			return
+		}
+		if opts.keepOrigins && config["mirror"] != nil && opts.msgsMode != copyMsgsNone {
+			sp.notify("A mirror can't take other messages, don't keep the mirror to copy messages", 5*time.Second, "error")
+			return
+		}
+		closeModal("copyModal")
+		go sp.runCopy(config, opts)
+	})
+	form.AddButton("Cancel", func() {
+		closeModal("copyModal")
+	})
+	form.SetCancelFunc(func() {
+		closeModal("copyModal")
+	})
+	showModal("copyModal", form, 90, 25)
+}
+
+// copyStreamConfig adapts the source configuration to the copy options
+func copyStreamConfig(config map[string]interface{}, opts streamCopyOptions) map[string]interface{} {
+	target := copyConfigMap(config)
+	target["name"] = opts.newName
+	target["num_replicas"] = opts.replicas
+	if opts.cluster != "" || len(opts.tags) > 0 {
+		placement := map[string]interface{}{}
+		if opts.cluster != "" {
+			placement["cluster"] = opts.cluster
+		}
+		if len(opts.tags) > 0 {
+			placement["tags"] = opts.tags
+		}
+		target["placement"] = placement
+	} else {
+		delete(target, "placement")
+	}
+	if !opts.keepOrigins {
+		delete(target, "mirror")
+		delete(target, "sources")
+	}
+
+	if opts.msgsMode == copyMsgsSource {
+		source := map[string]interface{}{"name": opts.stream}
+		if opts.apiPrefix != "" {
+			source["external"] = map[string]interface{}{"api": opts.apiPrefix}
+		}
+		sources, _ := target["sources"].([]interface{})
+		target["sources"] = append(sources, source)
+	}
+	return target
+}
+
+func (sp *StreamListPage) runCopy(config map[string]interface{}, opts streamCopyOptions) {
+	conn, closeConn, err := contextConn(opts.targetCtx)
+	if err != nil {
+		sp.progressNotify("Failed to connect to "+opts.targetCtx+": "+err.Error(), "error")
+		return
+	}
+	defer closeConn()
+
+	target := copyStreamConfig(config, opts)
+	if err := natsutil.CreateStreamRaw(conn, target); err != nil {
+		sp.progressNotify("Failed to create stream in "+opts.targetCtx+": "+err.Error(), "error")
+		return
+	}
+	logger.Info("Copied stream %s to %s as %s", opts.stream, opts.targetCtx, opts.newName)
+
+	targetJs, err := conn.JetStream()
+	if err != nil {
+		sp.progressNotify("Failed to get JetStream context of "+opts.targetCtx+": "+err.Error(), "error")
+		return
+	}
+
+	summary := fmt.Sprintf("Copied %s to %s as %s", opts.stream, opts.targetCtx, opts.newName)
+	if opts.copyConsumers {
+		copied, failed := copyConsumers(sp.Data.CurrCtx.Conn, targetJs, opts.stream, opts.newName)
+		summary += fmt.Sprintf(", %d consumers", copied)
+		if failed > 0 {
+			summary += fmt.Sprintf(" (%d failed, see log)", failed)
+		}
+	}
+
+	if opts.msgsMode == copyMsgsRepublish {
+		result, err := republishStream(sp.Data.CurrCtx.Conn, conn, opts.stream, func(n uint64) {
+			sp.progressNotify(fmt.Sprintf("Re-publishing %s to %s: %d messages", opts.stream, opts.targetCtx, n), "info")
+		})
+		sent, failed, firstErr := result.counts()
+		if err != nil {
+			sp.progressNotify(fmt.Sprintf("Re-publishing failed after %d messages: %v", sent, err), "error")
+			return
+		}
+		summary += fmt.Sprintf(", %d messages re-published", sent-failed)
+		if failed > 0 {
+			logger.Error("%d of %d messages of %s were not stored in %s, first error: %v", failed, sent, opts.stream, opts.newName, firstErr)
+			summary += fmt.Sprintf(", %d rejected (%v)", failed, firstErr)
+			sp.progressNotify(summary, "error")
+			return
+		}
+	}
+	sp.progressNotify(summary, "info")
+}
+
+// copyConsumers recreates the durable consumers of the stream. Ephemeral
+// consumers belong to running clients and are skipped. A failure to list the
+// consumers counts as one failed copy.
+func copyConsumers(srcConn *nats.Conn, targetJs nats.JetStreamContext, stream, targetStream string) (copied, failed int) {
+	infos, err := natsutil.ListConsumers(srcConn, stream)
+	if err != nil {
+		logger.Error("Failed to list the consumers of %s: %v", stream, err)
+		return 0, 1
+	}
+	for _, info := range infos {
+		if info.Config.Durable == "" {
+			continue
+		}
+		config := info.Config
+		if _, err := targetJs.AddConsumer(targetStream, &config); err != nil {
+			logger.Error("Failed to copy consumer %s of %s: %v", info.Name, stream, err)
+			failed++
+			continue
+		}
+		copied++
+	}
+	return copied, failed
+}
+
+// republishResult counts the messages sent and the ones the target rejected.
+// Rejections are reported from the async ack handler, so all access is locked.
+type republishResult struct {
+	mu          sync.Mutex
+	sentCount   uint64
+	failedCount uint64
+	firstErr    error
+}
+
+func (r *republishResult) addSent() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sentCount++
+}
+
+func (r *republishResult) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failedCount++
+	if r.firstErr == nil {
+		r.firstErr = err
+	}
+}
+
+// counts returns the messages sent, the ones rejected and the first rejection
+func (r *republishResult) counts() (sent, failed uint64, firstErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sentCount, r.failedCount, r.firstErr
+}
+
+// republishStream publishes every message of the stream to the target with
+// the original subject and headers. Sequence numbers aren't kept. Messages
+// the target doesn't store, because no stream listens on the subject or a
+// limit rejects them, are counted as failed.
+func republishStream(srcConn, targetConn *nats.Conn, stream string, progress func(n uint64)) (*republishResult, error) {
+	result := &republishResult{}
+	targetJs, err := targetConn.JetStream(nats.PublishAsyncErrHandler(func(_ nats.JetStream, _ *nats.Msg, err error) {
+		result.fail(err)
+	}))
+	if err != nil {
+		return result, err
+	}
+
+	report := throttle(500*time.Millisecond, func() {
+		sent, _, _ := result.counts()
+		progress(sent)
+	})
+	seq := uint64(1)
+	for {
+		msg, err := natsutil.GetNextMsg(srcConn, stream, seq, "")
+		if natsutil.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		out := &nats.Msg{Subject: msg.Subject, Data: msg.Data}
+		if len(msg.Header) > 0 {
+			out.Header = parseRawHeaders(msg.Header)
+		}
+		if _, err := targetJs.PublishMsgAsync(out); err != nil {
+			return result, err
+		}
+		result.addSent()
+		report()
+		seq = msg.Sequence + 1
+	}
+
+	select {
+	case <-targetJs.PublishAsyncComplete():
+	case <-time.After(time.Minute):
+		return result, fmt.Errorf("timed out waiting for publish acks")
+	}
+	return result, nil
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"gopkg.in/yaml.v2"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
 )
@@ -16,7 +19,9 @@ type StreamInfoPage struct {
 	Data       *ds.Data
 	textArea   *tview.TextArea
 	footerTxt  *tview.TextView
+	originsTxt *tview.TextView
 	streamName string
+	stopCh     chan struct{}
 }
 
 func NewStreamInfoPage(app *tview.Application, data *ds.Data) *StreamInfoPage {
@@ -42,6 +47,12 @@ func (sap *StreamInfoPage) setupUI() {
 	headerRow.SetTitle("STREAM INFO")
 	sap.AddItem(headerRow, 3, 1, false)
 
+	// Live mirror / sources state, hidden for streams without origins
+	sap.originsTxt = tview.NewTextView().SetDynamicColors(true)
+	sap.originsTxt.SetBorder(true)
+	sap.originsTxt.SetTitle("Mirror / Sources")
+	sap.AddItem(sap.originsTxt, 0, 0, false)
+
 	// Text area for YAML
 	sap.textArea = tview.NewTextArea()
 	sap.textArea.SetBorder(true)
@@ -72,6 +83,7 @@ func (sap *StreamInfoPage) setupInputCapture() {
 }
 
 func (sap *StreamInfoPage) goBack() {
+	sap.stopRefresh()
 	pages.SwitchToPage("streamListPage")
 	_, b := pages.GetFrontPage()
 	b.(*StreamListPage).redraw(&sap.Data.CurrCtx)
@@ -120,10 +132,96 @@ func (sap *StreamInfoPage) redraw(ctx *ds.Context) {
 
 	yamlTxt := string(yamlBytes)
 	sap.textArea.SetText(yamlTxt, false)
+	sap.showOrigins(stream)
+	if stream.Mirror != nil || len(stream.Sources) > 0 {
+		sap.startRefresh(js)
+	}
 	go sap.app.Draw()
     
 }
 
+// startRefresh keeps the mirror / sources lag up to date while the page is shown
+func (sap *StreamInfoPage) startRefresh(js nats.JetStreamContext) {
+	sap.stopRefresh()
+	stopCh := make(chan struct{})
+	sap.stopCh = stopCh
+	streamName := sap.streamName
+
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				stream, err := js.StreamInfo(streamName)
+				sap.app.QueueUpdateDraw(func() {
+					select {
+					case <-stopCh:
+						return
+					default:
+					}
+					if err != nil {
+						sap.notify("Failed to refresh stream info: "+err.Error(), 3*time.Second, "error")
+						return
+					}
+					sap.showOrigins(stream)
+				})
+			}
+		}
+	}()
+}
+
+func (sap *StreamInfoPage) stopRefresh() {
+	if sap.stopCh != nil {
+		close(sap.stopCh)
+		sap.stopCh = nil
+	}
+}
+
+func (sap *StreamInfoPage) showOrigins(stream *nats.StreamInfo) {
+	origins := make([]string, 0)
+	if stream.Mirror != nil {
+		origins = append(origins, formatStreamSourceInfo("mirror", stream.Mirror))
+	}
+	for _, source := range stream.Sources {
+		origins = append(origins, formatStreamSourceInfo("source", source))
+	}
+	if len(origins) == 0 {
+		sap.ResizeItem(sap.originsTxt, 0, 0)
+		return
+	}
+	sap.originsTxt.SetTitle("Mirror / Sources (updated " + time.Now().Format("15:04:05") + ")")
+	sap.originsTxt.SetText(strings.Join(origins, "\n"))
+	sap.ResizeItem(sap.originsTxt, min(len(origins), 8)+2, 0)
+}
+
+func formatStreamSourceInfo(kind string, info *nats.StreamSourceInfo) string {
+	lagColor := "green"
+	if info.Lag > 0 {
+		lagColor = "yellow"
+	}
+	lastSeen := "never"
+	if info.Active >= 0 {
+		lastSeen = info.Active.Round(time.Millisecond).String() + " ago"
+	}
+	line := fmt.Sprintf("%-6s [::b]%s[::-]  lag [%s]%d[-]  last seen %s", kind, tview.Escape(info.Name), lagColor, info.Lag, lastSeen)
+	if info.FilterSubject != "" {
+		line += "  filter " + tview.Escape(info.FilterSubject)
+	}
+	for _, t := range info.SubjectTransforms {
+		line += "  " + tview.Escape(t.Source+" -> "+t.Destination)
+	}
+	if info.External != nil {
+		line += "  via " + tview.Escape(info.External.APIPrefix)
+	}
+	if info.Error != nil {
+		line += "  [red]" + tview.Escape(info.Error.Error()) + "[-]"
+	}
+	return line
+}
+
 func (sap *StreamInfoPage) notify(message string, duration time.Duration, logLevel string) {
 	sap.footerTxt.SetText(message)
 	sap.footerTxt.SetTextColor(getLogLevelColor(logLevel))
@@ -1,22 +1,84 @@
 package main
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
 	"github.com/rivo/tview"
 	"github.com/solidpulse/natsdash/ds"
 	"github.com/solidpulse/natsdash/logger"
+	"github.com/solidpulse/natsdash/natsutil"
 )
 
 type StreamListPage struct {
 	*tview.Flex
-	Data         *ds.Data
-	streamList   *tview.List
-	app          *tview.Application
-	footerTxt    *tview.TextView
-	deleteConfirmStream string    // stream pending deletion
+	Data                *ds.Data
+	streamTable         *tview.Table
+	tableBox            *tview.Flex
+	searchInput         *tview.InputField
+	app                 *tview.Application
+	footerTxt           *tview.TextView
+	deleteConfirmStream string // stream pending deletion
 	deleteConfirmTimer  *time.Timer
+	streams             []*nats.StreamInfo
+	visible             []*nats.StreamInfo // streams after search and sort
+	sortCol             int
+	sortDesc            bool
+	refreshGen          int
+}
+
+const streamListRefreshInterval = 5 * time.Second
+
+// streamColumn is a column of the stream table
+type streamColumn struct {
+	title string
+	value func(s *nats.StreamInfo) string
+	less  func(a, b *nats.StreamInfo) bool
+	right bool // right aligned
+}
+
+var streamColumns = []streamColumn{
+	{"Name", func(s *nats.StreamInfo) string { return s.Config.Name },
+		func(a, b *nats.StreamInfo) bool { return a.Config.Name < b.Config.Name }, false},
+	{"Subjects", streamSubjectsText,
+		func(a, b *nats.StreamInfo) bool { return streamSubjectsText(a) < streamSubjectsText(b) }, false},
+	{"Storage", func(s *nats.StreamInfo) string { return storageTypeToString(s.Config.Storage) },
+		func(a, b *nats.StreamInfo) bool { return a.Config.Storage < b.Config.Storage }, false},
+	{"Replicas", func(s *nats.StreamInfo) string { return strconv.Itoa(s.Config.Replicas) },
+		func(a, b *nats.StreamInfo) bool { return a.Config.Replicas < b.Config.Replicas }, true},
+	{"Messages", func(s *nats.StreamInfo) string { return humanCount(s.State.Msgs) },
+		func(a, b *nats.StreamInfo) bool { return a.State.Msgs < b.State.Msgs }, true},
+	{"Bytes", func(s *nats.StreamInfo) string { return humanBytes(s.State.Bytes) },
+		func(a, b *nats.StreamInfo) bool { return a.State.Bytes < b.State.Bytes }, true},
+	{"First Seq", func(s *nats.StreamInfo) string { return strconv.FormatUint(s.State.FirstSeq, 10) },
+		func(a, b *nats.StreamInfo) bool { return a.State.FirstSeq < b.State.FirstSeq }, true},
+	{"Last Seq", func(s *nats.StreamInfo) string { return strconv.FormatUint(s.State.LastSeq, 10) },
+		func(a, b *nats.StreamInfo) bool { return a.State.LastSeq < b.State.LastSeq }, true},
+	{"Last Activity", func(s *nats.StreamInfo) string { return humanAgo(s.State.LastTime) },
+		func(a, b *nats.StreamInfo) bool { return a.State.LastTime.Before(b.State.LastTime) }, true},
+	{"Consumers", func(s *nats.StreamInfo) string { return strconv.Itoa(s.State.Consumers) },
+		func(a, b *nats.StreamInfo) bool { return a.State.Consumers < b.State.Consumers }, true},
+	{"Leader", streamLeader,
+		func(a, b *nats.StreamInfo) bool { return streamLeader(a) < streamLeader(b) }, false},
+}
+
+func streamSubjectsText(s *nats.StreamInfo) string {
+	if s.Config.Mirror != nil {
+		return "mirror of " + s.Config.Mirror.Name
+	}
+	return strings.Join(s.Config.Subjects, ", ")
+}
+
+func streamLeader(s *nats.StreamInfo) string {
+	if s.Cluster == nil {
+		return ""
+	}
+	return s.Cluster.Leader
 }
 
 func NewStreamListPage(app *tview.Application, data *ds.Data) *StreamListPage {
@@ -37,15 +99,33 @@ func (sp *StreamListPage) setupUI() {
 	headerRow := createStreamListHeaderRow()
 	sp.AddItem(headerRow, 4, 4, false)
 
-	// Stream list setup
+	// Search
+	sp.searchInput = tview.NewInputField().
+		SetLabel("Search: ").
+		SetPlaceholder("press / to search names and subjects").
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	sp.searchInput.SetChangedFunc(func(text string) {
+		sp.renderTable()
+	})
+	sp.searchInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			sp.searchInput.SetText("")
+		}
+		sp.app.SetFocus(sp.streamTable)
+	})
+	sp.searchInput.SetBorderPadding(0, 0, 1, 1)
+	sp.AddItem(sp.searchInput, 1, 0, false)
+
+	// Stream table setup
 	streamListBox := tview.NewFlex()
 	streamListBox.SetTitle("Streams").SetBorder(true)
-	sp.streamList = tview.NewList().
-		ShowSecondaryText(false).
-		SetHighlightFullLine(true)
-	streamListBox.AddItem(sp.streamList, 0, 20, false)
+	sp.tableBox = streamListBox
+	sp.streamTable = tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 1)
+	streamListBox.AddItem(sp.streamTable, 0, 20, true)
 	streamListBox.SetBorderPadding(0, 0, 1, 1)
-	sp.AddItem(streamListBox, 0, 18, false)
+	sp.AddItem(streamListBox, 0, 18, true)
 
 	// Footer setup
 	footer := tview.NewFlex()
@@ -57,55 +137,135 @@ func (sp *StreamListPage) setupUI() {
 	footer.AddItem(sp.footerTxt, 0, 1, false)
 	sp.AddItem(footer, 3, 2, false)
 	sp.SetBorderPadding(1, 0, 1, 1)
+}
 
-	// Add dummy streams for testing
+// redraw loads the streams in the background and keeps refreshing them while
+// the page is visible
+func (sp *StreamListPage) redraw(ctx *ds.Context) {
+	sp.refreshGen++
+	gen := sp.refreshGen
+	conn := ctx.Conn
+	sp.app.SetFocus(sp.streamTable)
 
+	go func() {
+		for first := true; ; first = false {
+			streams, err := natsutil.ListStreams(conn)
+			keepGoing := make(chan bool, 1)
+			sp.app.QueueUpdateDraw(func() {
+				if gen != sp.refreshGen {
+					keepGoing <- false
+					return
+				}
+				if err != nil {
+					logger.Error("Failed to list streams: %v", err)
+					sp.notify("Failed to list streams: "+err.Error(), 3*time.Second, "error")
+				} else {
+					sp.streams = streams
+					sp.renderTable()
+					if first && len(streams) == 0 {
+						sp.notify("No streams found", 3*time.Second, "info")
+					}
+				}
+				keepGoing <- isPageVisible("streamListPage")
+			})
+			if !<-keepGoing {
+				return
+			}
+			time.Sleep(streamListRefreshInterval)
+		}
+	}()
 }
 
-func (sp *StreamListPage) redraw(ctx *ds.Context) {
-    sp.streamList.Clear()
-    
-    // Connect to NATS
-    conn := ctx.Conn
-
-    // Get JetStream context
-    js, err := conn.JetStream()
-    if err != nil {
-        logger.Error("Failed to get JetStream context: %v", err)
-        sp.notify("Failed to get JetStream context", 3*time.Second, "error")
-        return
-    }
-
-    // List all streams
-    streams := make([]string, 0)
-    for stream := range js.StreamNames() {
-        streams = append(streams, stream)
-    }
-
-    // Add streams to the list
-    for _, stream := range streams {
-        sp.streamList.AddItem(stream, "", 0, nil)
-    }
-
-    if len(streams) == 0 {
-        sp.notify("No streams found", 3*time.Second, "info")
-    }
-
-	go sp.app.Draw()
+// renderTable filters and sorts the streams and fills the table, keeping the
+// selected stream selected
+func (sp *StreamListPage) renderTable() {
+	selected, _ := sp.selectedStream()
+
+	filter := strings.ToLower(sp.searchInput.GetText())
+	sp.visible = sp.visible[:0]
+	for _, s := range sp.streams {
+		if filter == "" || strings.Contains(strings.ToLower(s.Config.Name+" "+strings.Join(s.Config.Subjects, " ")), filter) {
+			sp.visible = append(sp.visible, s)
+		}
+	}
+	col := streamColumns[sp.sortCol]
+	sort.SliceStable(sp.visible, func(i, j int) bool {
+		if sp.sortDesc {
+			return col.less(sp.visible[j], sp.visible[i])
+		}
+		return col.less(sp.visible[i], sp.visible[j])
+	})
+
+	sp.streamTable.Clear()
+	for c, column := range streamColumns {
+		title := column.title
+		if c == sp.sortCol {
+			if sp.sortDesc {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		cell := tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1)
+		if column.right {
+			cell.SetAlign(tview.AlignRight)
+		}
+		sp.streamTable.SetCell(0, c, cell)
+	}
+
+	selectedRow := 1
+	for r, s := range sp.visible {
+		for c, column := range streamColumns {
+			cell := tview.NewTableCell(" " + column.value(s) + " ").SetMaxWidth(40)
+			if column.right {
+				cell.SetAlign(tview.AlignRight)
+			}
+			sp.streamTable.SetCell(r+1, c, cell)
+		}
+		if s.Config.Name == selected {
+			selectedRow = r + 1
+		}
+	}
+	if len(sp.visible) > 0 {
+		sp.streamTable.Select(selectedRow, 0)
+	}
+
+	title := fmt.Sprintf("Streams (%d)", len(sp.streams))
+	if filter != "" {
+		title = fmt.Sprintf("Streams (%d of %d)", len(sp.visible), len(sp.streams))
+	}
+	sp.tableBox.SetTitle(title)
+}
+
+// selectedStream returns the name of the stream under the cursor
+func (sp *StreamListPage) selectedStream() (string, bool) {
+	row, _ := sp.streamTable.GetSelection()
+	if row < 1 || row > len(sp.visible) {
+		return "", false
+	}
+	return sp.visible[row-1].Config.Name, true
 }
 
 func (sp *StreamListPage) setupInputCapture() {
 	sp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Let the search field have its keys
+		if sp.searchInput.HasFocus() {
+			return event
+		}
+
 		switch event.Key() {
 		case tcell.KeyESC:
 			sp.goBackToContextPage()
 			return nil
 		case tcell.KeyUp, tcell.KeyDown:
-			sp.app.SetFocus(sp.streamList)
+			sp.app.SetFocus(sp.streamTable)
 		case tcell.KeyRune:
 			switch event.Rune() {
 			case 'v', 'V':
-				if sp.streamList.GetItemCount() > 0 {
+				if _, ok := sp.selectedStream(); ok {
 					sp.viewStream()
 					return nil
 				}
@@ -113,18 +273,110 @@ func (sp *StreamListPage) setupInputCapture() {
 		}
 
 		switch event.Rune() {
+		case '/':
+			sp.app.SetFocus(sp.searchInput)
+			return nil
+		case '<', '>':
+			// Move the sort to the previous or next column
+			delta := 1
+			if event.Rune() == '<' {
+				delta = len(streamColumns) - 1
+			}
+			sp.sortCol = (sp.sortCol + delta) % len(streamColumns)
+			sp.renderTable()
+			return nil
+		case 's', 'S':
+			sp.sortDesc = !sp.sortDesc
+			sp.renderTable()
+			return nil
+		case 'm', 'M':
+			streamName, ok := sp.selectedStream()
+			if !ok {
+				sp.notify("No stream selected", 3*time.Second, "error")
+				return event
+			}
+			logger.Info("Message browser action triggered for: %s", streamName)
+			pages.SwitchToPage("streamBrowserPage")
+			_, b := pages.GetFrontPage()
+			b.(*StreamBrowserPage).returnPage = "streamListPage"
+			b.(*StreamBrowserPage).open(&sp.Data.CurrCtx, streamName, "")
+			return nil
+		case 'u', 'U':
+			streamName, ok := sp.selectedStream()
+			if !ok {
+				sp.notify("No stream selected", 3*time.Second, "error")
+				return event
+			}
+			logger.Info("Subject explorer action triggered for: %s", streamName)
+			pages.SwitchToPage("subjectExplorerPage")
+			_, b := pages.GetFrontPage()
+			b.(*SubjectExplorerPage).open(&sp.Data.CurrCtx, streamName)
+			return nil
+		case 'k', 'K':
+			streamName, ok := sp.selectedStream()
+			if !ok {
+				sp.notify("No stream selected", 3*time.Second, "error")
+				return event
+			}
+			logger.Info("Backup stream action triggered for: %s", streamName)
+			sp.showBackupDialog(streamName)
+			return nil
+		case 'r', 'R':
+			logger.Info("Restore stream action triggered")
+			sp.showRestoreDialog()
+			return nil
+		case 'f', 'F':
+			logger.Info("Apply manifest action triggered")
+			pages.SwitchToPage("applyPage")
+			_, b := pages.GetFrontPage()
+			b.(*ApplyPage).redraw(&sp.Data.CurrCtx)
+			return nil
+		case 'x', 'X':
+			logger.Info("Export manifest action triggered")
+			sp.showExportDialog()
+			return nil
+		case 'y', 'Y':
+			streamName, ok := sp.selectedStream()
+			if !ok {
+				sp.notify("No stream selected", 3*time.Second, "error")
+				return event
+			}
+			logger.Info("Copy stream action triggered for: %s", streamName)
+			sp.showCopyDialog(streamName)
+			return nil
+		case 'p', 'P':
+			streamName, ok := sp.selectedStream()
+			if !ok {
+				sp.notify("No stream selected", 3*time.Second, "error")
+				return event
+			}
+			logger.Info("Purge stream action triggered for: %s", streamName)
+			sp.showPurgeDialog(streamName)
+			return nil
+		case 'b', 'B':
+			logger.Info("Benchmark action triggered")
+			pages.SwitchToPage("benchPage")
+			_, b := pages.GetFrontPage()
+			benchPage := b.(*BenchPage)
+			benchPage.returnPage = "streamListPage"
+			benchPage.redraw(&sp.Data.CurrCtx)
+			return nil
 		case 'a', 'A':
 			logger.Info("Add stream action triggered")
-			pages.SwitchToPage("streamAddPage")
-			_, b := pages.GetFrontPage()
-			b.(*StreamAddPage).redraw(&data.CurrCtx)
+			sp.showTemplatePicker(func(values map[string]interface{}) {
+				pages.SwitchToPage("streamAddPage")
+				_, b := pages.GetFrontPage()
+				addPage := b.(*StreamAddPage)
+				addPage.setAddMode(values)
+				addPage.redraw(&data.CurrCtx)
+			})
+			return nil
 		case 'e', 'E':
-			if sp.streamList.GetItemCount() == 0 {
+			streamName, ok := sp.selectedStream()
+			if !ok {
 				sp.notify("No stream selected", 3*time.Second, "error")
 				return event
 			}
-			idx := sp.streamList.GetCurrentItem()
-			streamName,_ := sp.streamList.GetItemText(idx)
 			logger.Info("Edit stream action triggered for: %s", streamName)
 			pages.SwitchToPage("streamAddPage")
 			_, b := pages.GetFrontPage()
@@ -133,12 +385,11 @@ func (sp *StreamListPage) setupInputCapture() {
 			addPage.redraw(&sp.Data.CurrCtx)
 		case 'i', 'I':
 			logger.Info("Stream info action triggered")
-			if sp.streamList.GetItemCount() == 0 {
+			streamName, ok := sp.selectedStream()
+			if !ok {
 				sp.notify("No stream selected", 3*time.Second, "error")
 				return event
 			}
-			idx := sp.streamList.GetCurrentItem()
-			streamName, _ := sp.streamList.GetItemText(idx)
 			logger.Info("Stream info action triggered for: %s", streamName)
 			pages.SwitchToPage("streamInfoPage")
 			_, b := pages.GetFrontPage()
@@ -146,26 +397,24 @@ func (sp *StreamListPage) setupInputCapture() {
 			infoPage.streamName = streamName
 			infoPage.redraw(&sp.Data.CurrCtx)
 		case 'c', 'C':
-			if sp.streamList.GetItemCount() == 0 {
+			streamName, ok := sp.selectedStream()
+			if !ok {
 				sp.notify("No stream selected", 3*time.Second, "error")
 				return event
 			}
-			idx := sp.streamList.GetCurrentItem()
-			streamName, _ := sp.streamList.GetItemText(idx)
 			logger.Info("consumer list action triggered for: %s", streamName)
 			pages.SwitchToPage("consumerListPage")
 			_, b := pages.GetFrontPage()
 			infoPage := b.(*ConsumerListPage)
 			infoPage.streamName = streamName
 			infoPage.redraw(&sp.Data.CurrCtx)
-		case 'd', 'D': 
-			if sp.streamList.GetItemCount() == 0 {
+		case 'd', 'D':
+			streamName, ok := sp.selectedStream()
+			if !ok {
 				sp.notify("No stream selected", 3*time.Second, "error")
 				return event
 			}
-			idx := sp.streamList.GetCurrentItem()
-			streamName, _ := sp.streamList.GetItemText(idx)
-			
+
 			if sp.deleteConfirmStream == streamName {
 				// Second press - execute delete
 				sp.deleteConfirmTimer.Stop()
@@ -182,7 +431,6 @@ func (sp *StreamListPage) setupInputCapture() {
 	})
 }
 
-
 func (cfp *StreamListPage) goBackToContextPage() {
 
 	pages.SwitchToPage("contexts")
@@ -191,7 +439,6 @@ func (cfp *StreamListPage) goBackToContextPage() {
 	cfp.app.SetFocus(b) // Add this line
 }
 
-
 func (sp *StreamListPage) notify(message string, duration time.Duration, logLevel string) {
 	sp.footerTxt.SetText(message)
 	sp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
@@ -206,12 +453,12 @@ func (sp *StreamListPage) notify(message string, duration time.Duration, logLeve
 func (sp *StreamListPage) startDeleteConfirmation(streamName string) {
 	sp.deleteConfirmStream = streamName
 	sp.notify("Press d again within 10 seconds to confirm deletion of '"+streamName+"'", 10*time.Second, "warning")
-	
+
 	// Cancel any existing timer
 	if sp.deleteConfirmTimer != nil {
 		sp.deleteConfirmTimer.Stop()
 	}
-	
+
 	// Start new timer
 	sp.deleteConfirmTimer = time.NewTimer(10 * time.Second)
 	go func() {
@@ -240,44 +487,64 @@ func (sp *StreamListPage) executeDelete(streamName string) {
 }
 
 func createStreamListHeaderRow() *tview.Flex {
-    container := tview.NewFlex()
-    container.SetBorder(false)
-    container.
-        SetDirection(tview.FlexColumn).
-        SetBorderPadding(0, 0, 1, 1)
-
-    col1 := tview.NewFlex()
-    col1.SetDirection(tview.FlexRow)
-    col1.SetBorder(false)
-
-    col1.AddItem(createTextView("[ESC] Back", tcell.ColorWhite), 0, 1, false)
-    col1.AddItem(createTextView(" ", tcell.ColorWhite), 0, 1, false)
-    col1.AddItem(createTextView(" ", tcell.ColorWhite), 0, 1, false)
-
-    col2 := tview.NewFlex()
-    col2.SetDirection(tview.FlexRow)
-    col2.SetBorder(false)
-
-    col2.AddItem(createTextView("[i] Info", tcell.ColorWhite), 0, 1, false)
-    col2.AddItem(createTextView("[c] Consumers", tcell.ColorWhite), 0, 1, false)
-    col2.AddItem(createTextView("[v] View", tcell.ColorWhite), 0, 1, false)
+	container := tview.NewFlex()
+	container.SetBorder(false)
+	container.
+		SetDirection(tview.FlexColumn).
+		SetBorderPadding(0, 0, 1, 1)
+
+	col1 := tview.NewFlex()
+	col1.SetDirection(tview.FlexRow)
+	col1.SetBorder(false)
+
+	col1.AddItem(createTextView("[ESC] Back", tcell.ColorWhite), 0, 1, false)
+	col1.AddItem(createTextView("[b] Benchmark", tcell.ColorWhite), 0, 1, false)
+	col1.AddItem(createTextView("[/] Search", tcell.ColorWhite), 0, 1, false)
+	col1.AddItem(createTextView("[u] Subjects", tcell.ColorWhite), 0, 1, false)
+
+	col2 := tview.NewFlex()
+	col2.SetDirection(tview.FlexRow)
+	col2.SetBorder(false)
+
+	col2.AddItem(createTextView("[i] Info", tcell.ColorWhite), 0, 1, false)
+	col2.AddItem(createTextView("[c] Consumers", tcell.ColorWhite), 0, 1, false)
+	col2.AddItem(createTextView("[v] View", tcell.ColorWhite), 0, 1, false)
+	col2.AddItem(createTextView("[m] Messages", tcell.ColorWhite), 0, 1, false)
 
 	col3 := tview.NewFlex()
 	col3.SetDirection(tview.FlexRow)
 	col3.SetBorder(false)
 	col3.AddItem(createTextView("[a] Add", tcell.ColorWhite), 0, 1, false)
-	col3.AddItem(createTextView("[e] Edit", tcell.ColorWhite), 0, 1, false)	
+	col3.AddItem(createTextView("[e] Edit", tcell.ColorWhite), 0, 1, false)
 	col3.AddItem(createTextView("[d] Delete", tcell.ColorWhite), 0, 1, false)
-
-    container.AddItem(col1, 0, 1, false)
-    container.AddItem(col2, 0, 1, false)
+	col3.AddItem(createTextView("[p] Purge", tcell.ColorWhite), 0, 1, false)
+
+	col4 := tview.NewFlex()
+	col4.SetDirection(tview.FlexRow)
+	col4.SetBorder(false)
+	col4.AddItem(createTextView("[</>] Sort Column", tcell.ColorWhite), 0, 1, false)
+	col4.AddItem(createTextView("[s] Sort Order", tcell.ColorWhite), 0, 1, false)
+	col4.AddItem(createTextView("[f] Apply Manifest", tcell.ColorWhite), 0, 1, false)
+	col4.AddItem(createTextView("[x] Export Manifest", tcell.ColorWhite), 0, 1, false)
+
+	col5 := tview.NewFlex()
+	col5.SetDirection(tview.FlexRow)
+	col5.SetBorder(false)
+	col5.AddItem(createTextView("[k] Backup", tcell.ColorWhite), 0, 1, false)
+	col5.AddItem(createTextView("[r] Restore", tcell.ColorWhite), 0, 1, false)
+	col5.AddItem(createTextView("[y] Copy to Context", tcell.ColorWhite), 0, 1, false)
+
+	container.AddItem(col1, 0, 1, false)
+	container.AddItem(col2, 0, 1, false)
 	container.AddItem(col3, 0, 1, false)
-    container.SetTitle("STREAMS")
+	container.AddItem(col4, 0, 1, false)
+	container.AddItem(col5, 0, 1, false)
+	container.SetTitle("STREAMS")
 
-    return container
+	return container
 }
 func (sp *StreamListPage) viewStream() {
-	streamName, _ := sp.streamList.GetItemText(sp.streamList.GetCurrentItem())
+	streamName, _ := sp.selectedStream()
 	pages.SwitchToPage("streamViewPage")
 	_, viewPage := pages.GetFrontPage()
 	viewPage.(*StreamViewPage).streamName = streamName
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/logger"
+)
+
+const (
+	purgeModeAll  = "Everything matching"
+	purgeModeSeq  = "Up to sequence (exclusive)"
+	purgeModeKeep = "Keep last N"
+)
+
+// purgeEstimate is what a purge is expected to remove
+type purgeEstimate struct {
+	msgs       uint64
+	bytes      uint64
+	msgsExact  bool // false when msgs is an upper bound
+	bytesExact bool // false when bytes is estimated from the average size
+}
+
+// showPurgeDialog asks for the purge options of the stream
+func (sp *StreamListPage) showPurgeDialog(streamName string) {
+	js, err := sp.Data.CurrCtx.Conn.JetStream()
+	if err != nil {
+		sp.notify("Failed to get JetStream context: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	info, err := js.StreamInfo(streamName)
+	if err != nil {
+		sp.notify("Failed to get stream info: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	if info.Config.DenyPurge {
+		sp.notify("Stream '"+streamName+"' denies purging (deny_purge)", 3*time.Second, "error")
+		return
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle("Purge " + streamName)
+	form.AddInputField("Subject Filter", "", 0, nil, nil)
+	form.AddDropDown("Mode", []string{purgeModeAll, purgeModeSeq, purgeModeKeep}, 0, nil)
+	form.AddInputField("Sequence / Keep", "", 20, tview.InputFieldInteger, nil)
+	form.AddButton("Continue", func() {
+		req, err := purgeRequestFromForm(form)
+		if err != nil {
+			sp.notify(err.Error(), 3*time.Second, "error")
+			return
+		}
+		closeModal("purgeModal")
+		sp.confirmPurge(js, streamName, req)
+	})
+	form.AddButton("Cancel", func() {
+		closeModal("purgeModal")
+	})
+	form.SetCancelFunc(func() {
+		closeModal("purgeModal")
+	})
+	showModal("purgeModal", form, 70, 13)
+}
+
+func purgeRequestFromForm(form *tview.Form) (*nats.StreamPurgeRequest, error) {
+	req := &nats.StreamPurgeRequest{
+		Subject: strings.TrimSpace(form.GetFormItemByLabel("Subject Filter").(*tview.InputField).GetText()),
+	}
+	if req.Subject != "" && !isValidSubject(req.Subject) {
+		return nil, fmt.Errorf("invalid subject filter %q", req.Subject)
+	}
+
+	_, mode := form.GetFormItemByLabel("Mode").(*tview.DropDown).GetCurrentOption()
+	if mode == purgeModeAll {
+		return req, nil
+	}
+	text := strings.TrimSpace(form.GetFormItemByLabel("Sequence / Keep").(*tview.InputField).GetText())
+	n, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("a number is required for %q", mode)
+	}
+	if mode == purgeModeSeq {
+		if n == 0 {
+			return nil, fmt.Errorf("the sequence must be greater than 0")
+		}
+		req.Sequence = n
+	} else {
+		req.Keep = n
+	}
+	return req, nil
+}
+
+// estimatePurge computes what the purge removes from the stream state. With
+// a subject filter the per subject counts are used. Bytes are estimated from
+// the average message size unless the whole stream goes.
+func estimatePurge(js nats.JetStreamContext, streamName string, req *nats.StreamPurgeRequest) (purgeEstimate, error) {
+	opts := make([]nats.JSOpt, 0)
+	if req.Subject != "" {
+		opts = append(opts, &nats.StreamInfoRequest{SubjectsFilter: req.Subject})
+	}
+	info, err := js.StreamInfo(streamName, opts...)
+	if err != nil {
+		return purgeEstimate{}, err
+	}
+
+	state := info.State
+	est := purgeEstimate{msgs: state.Msgs, msgsExact: true}
+	if req.Subject != "" {
+		est.msgs = 0
+		for _, count := range state.Subjects {
+			est.msgs += count
+		}
+	}
+
+	switch {
+	case req.Keep > 0:
+		if est.msgs > req.Keep {
+			est.msgs -= req.Keep
+		} else {
+			est.msgs = 0
+		}
+	case req.Sequence > 0:
+		below := uint64(0)
+		if req.Sequence > state.FirstSeq {
+			below = req.Sequence - state.FirstSeq
+		}
+		if below < est.msgs {
+			est.msgs = below
+			// Deleted messages or other subjects inside the range make
+			// this an upper bound
+			est.msgsExact = state.NumDeleted == 0 && req.Subject == ""
+		}
+	}
+
+	if est.msgs == state.Msgs {
+		est.bytes = state.Bytes
+		est.bytesExact = est.msgsExact
+	} else if state.Msgs > 0 {
+		est.bytes = uint64(float64(state.Bytes) / float64(state.Msgs) * float64(est.msgs))
+	}
+	return est, nil
+}
+
+func (sp *StreamListPage) confirmPurge(js nats.JetStreamContext, streamName string, req *nats.StreamPurgeRequest) {
+	est, err := estimatePurge(js, streamName, req)
+	if err != nil {
+		sp.notify("Failed to estimate purge: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Stream:  " + tview.Escape(streamName) + "\n")
+	if req.Subject != "" {
+		sb.WriteString("Subject: " + tview.Escape(req.Subject) + "\n")
+	}
+	if req.Sequence > 0 {
+		sb.WriteString(fmt.Sprintf("Purge messages below sequence %d\n", req.Sequence))
+	}
+	if req.Keep > 0 {
+		sb.WriteString(fmt.Sprintf("Keep the last %d messages\n", req.Keep))
+	}
+	msgsPrefix, bytesPrefix := "", ""
+	if !est.msgsExact {
+		msgsPrefix = "up to "
+	}
+	if !est.bytesExact {
+		bytesPrefix = "~"
+	}
+	sb.WriteString(fmt.Sprintf("\n[red::b]Removes %s%s messages (%s%s)[-::-]\n", msgsPrefix, humanCount(est.msgs), bytesPrefix, humanBytes(est.bytes)))
+
+	showConfirmModal("Confirm purge", sb.String(), func() {
+		sp.executePurge(js, streamName, req)
+	})
+}
+
+func (sp *StreamListPage) executePurge(js nats.JetStreamContext, streamName string, req *nats.StreamPurgeRequest) {
+	logger.Info("Purging stream %s (subject %q, seq %d, keep %d)", streamName, req.Subject, req.Sequence, req.Keep)
+	if err := js.PurgeStream(streamName, req); err != nil {
+		sp.notify("Failed to purge stream: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	sp.notify("Stream '"+streamName+"' purged successfully", 3*time.Second, "info")
+	sp.redraw(&sp.Data.CurrCtx)
+}
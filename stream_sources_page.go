@@ -0,0 +1,477 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+)
+
+const (
+	originTypeSource = "Source"
+	originTypeMirror = "Mirror"
+)
+
+// streamOrigin is a mirror or source entry of a stream config. raw keeps the
+// entry as the server sent it so fields not shown in the form are preserved.
+type streamOrigin struct {
+	mirror bool
+	raw    map[string]interface{}
+}
+
+// StreamSourcesPage edits the mirror and sources of the stream being
+// edited on StreamAddPage
+type StreamSourcesPage struct {
+	*tview.Flex
+	app        *tview.Application
+	Data       *ds.Data
+	originList *tview.List
+	form       *tview.Form
+	footerTxt  *tview.TextView
+	origins    []*streamOrigin
+	current    int
+	refreshing bool
+	values     map[string]interface{}
+	onApply    func(values map[string]interface{})
+}
+
+func NewStreamSourcesPage(app *tview.Application, data *ds.Data) *StreamSourcesPage {
+	ssp := &StreamSourcesPage{
+		Flex:    tview.NewFlex().SetDirection(tview.FlexRow),
+		app:     app,
+		Data:    data,
+		current: -1,
+	}
+
+	ssp.setupUI()
+	ssp.setupInputCapture()
+	return ssp
+}
+
+func (ssp *StreamSourcesPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[ESC] Back/Cancel", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Enter] Edit Origin", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Alt+Enter] Apply to Stream", tcell.ColorWhite), 0, 1, false)
+	ssp.AddItem(headerRow, 3, 1, false)
+
+	body := tview.NewFlex().SetDirection(tview.FlexColumn)
+
+	ssp.originList = tview.NewList()
+	ssp.originList.ShowSecondaryText(true)
+	ssp.originList.SetBorder(true)
+	ssp.originList.SetTitle("Mirror / Sources")
+	ssp.originList.SetChangedFunc(func(index int, _ string, _ string, _ rune) {
+		if ssp.refreshing {
+			return
+		}
+		ssp.selectOrigin(index)
+	})
+	ssp.originList.SetSelectedFunc(func(index int, _ string, _ string, _ rune) {
+		ssp.app.SetFocus(ssp.form)
+	})
+	body.AddItem(ssp.originList, 0, 1, true)
+
+	ssp.form = tview.NewForm()
+	ssp.form.SetBorder(true)
+	ssp.form.SetTitle("Origin")
+	ssp.form.AddDropDown("Type", []string{originTypeSource, originTypeMirror}, 0, nil)
+	ssp.form.AddInputField("Stream Name", "", 0, nil, nil)
+	ssp.form.AddInputField("Start Sequence", "", 20, tview.InputFieldInteger, nil)
+	ssp.form.AddInputField("Start Time (RFC3339)", "", 0, nil, nil)
+	ssp.form.AddInputField("Filter Subject", "", 0, nil, nil)
+	ssp.form.AddInputField("Subject Transforms (src -> dest; ...)", "", 0, nil, nil)
+	ssp.form.AddInputField("External API Prefix", "", 0, nil, nil)
+	ssp.form.AddInputField("External Deliver Prefix", "", 0, nil, nil)
+	ssp.form.AddButton("Add Source", ssp.addOrigin)
+	ssp.form.AddButton("Remove", ssp.removeOrigin)
+	ssp.form.AddButton("Apply", ssp.apply)
+	body.AddItem(ssp.form, 0, 2, false)
+
+	ssp.AddItem(body, 0, 1, true)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	ssp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(ssp.footerTxt, 0, 1, false)
+	ssp.AddItem(footer, 3, 1, false)
+}
+
+func (ssp *StreamSourcesPage) setupInputCapture() {
+	ssp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			if !ssp.originList.HasFocus() && len(ssp.origins) > 0 {
+				if err := ssp.commitForm(); err != nil {
+					ssp.notify(err.Error(), 3*time.Second, "error")
+					return nil
+				}
+				ssp.refreshList()
+				ssp.app.SetFocus(ssp.originList)
+				return nil
+			}
+			ssp.goBack()
+			return nil
+		}
+		if event.Key() == tcell.KeyEnter && event.Modifiers() == tcell.ModAlt {
+			ssp.apply()
+			return nil
+		}
+		return event
+	})
+}
+
+// edit loads the mirror and sources of the editor values. onApply receives
+// the values with the edited mirror and sources.
+func (ssp *StreamSourcesPage) edit(values map[string]interface{}, onApply func(values map[string]interface{})) {
+	ssp.values = values
+	ssp.onApply = onApply
+	ssp.origins = nil
+	ssp.current = -1
+
+	if mirror, ok := values["mirror"].(map[string]interface{}); ok {
+		ssp.origins = append(ssp.origins, &streamOrigin{mirror: true, raw: mirror})
+	}
+	if sources, ok := values["sources"].([]interface{}); ok {
+		for _, s := range sources {
+			if source, ok := s.(map[string]interface{}); ok {
+				ssp.origins = append(ssp.origins, &streamOrigin{raw: source})
+			}
+		}
+	}
+
+	name, _ := values["name"].(string)
+	ssp.originList.SetTitle("Mirror / Sources of " + name)
+	ssp.refreshList()
+	if len(ssp.origins) > 0 {
+		ssp.selectOrigin(0)
+	} else {
+		ssp.clearForm()
+		ssp.notify("No mirror or sources yet, use \"Add Source\" to add one", 5*time.Second, "info")
+	}
+	ssp.app.SetFocus(ssp.originList)
+}
+
+func (ssp *StreamSourcesPage) refreshList() {
+	ssp.refreshing = true
+	defer func() { ssp.refreshing = false }()
+	ssp.originList.Clear()
+	for _, o := range ssp.origins {
+		name, _ := o.raw["name"].(string)
+		if name == "" {
+			name = "(unnamed)"
+		}
+		kind := "source"
+		if o.mirror {
+			kind = "mirror"
+		}
+		ssp.originList.AddItem(kind+": "+name, originSummary(o.raw), 0, nil)
+	}
+	if ssp.current >= 0 && ssp.current < len(ssp.origins) {
+		ssp.originList.SetCurrentItem(ssp.current)
+	}
+}
+
+func originSummary(raw map[string]interface{}) string {
+	parts := make([]string, 0)
+	if filter, _ := raw["filter_subject"].(string); filter != "" {
+		parts = append(parts, "filter "+filter)
+	}
+	if seq := configUint(raw["opt_start_seq"]); seq > 0 {
+		parts = append(parts, fmt.Sprintf("from seq %d", seq))
+	}
+	if start, _ := raw["opt_start_time"].(string); start != "" {
+		parts = append(parts, "from "+start)
+	}
+	if ext, ok := raw["external"].(map[string]interface{}); ok {
+		if api, _ := ext["api"].(string); api != "" {
+			parts = append(parts, "via "+api)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// selectOrigin saves the form into the previous origin and loads the new one
+func (ssp *StreamSourcesPage) selectOrigin(index int) {
+	if index == ssp.current || index < 0 || index >= len(ssp.origins) {
+		return
+	}
+	if ssp.current >= 0 && ssp.current < len(ssp.origins) {
+		if err := ssp.commitForm(); err != nil {
+			ssp.notify(err.Error(), 3*time.Second, "error")
+		}
+	}
+	ssp.current = index
+	ssp.loadForm(ssp.origins[index])
+}
+
+func (ssp *StreamSourcesPage) clearForm() {
+	ssp.loadForm(&streamOrigin{raw: map[string]interface{}{}})
+}
+
+func (ssp *StreamSourcesPage) loadForm(o *streamOrigin) {
+	typeIdx := 0
+	if o.mirror {
+		typeIdx = 1
+	}
+	ssp.form.GetFormItemByLabel("Type").(*tview.DropDown).SetCurrentOption(typeIdx)
+
+	name, _ := o.raw["name"].(string)
+	ssp.inputField("Stream Name").SetText(name)
+
+	startSeq := ""
+	if seq := configUint(o.raw["opt_start_seq"]); seq > 0 {
+		startSeq = strconv.FormatUint(seq, 10)
+	}
+	ssp.inputField("Start Sequence").SetText(startSeq)
+
+	startTime, _ := o.raw["opt_start_time"].(string)
+	ssp.inputField("Start Time (RFC3339)").SetText(startTime)
+
+	filter, _ := o.raw["filter_subject"].(string)
+	ssp.inputField("Filter Subject").SetText(filter)
+
+	ssp.inputField("Subject Transforms (src -> dest; ...)").SetText(formatSubjectTransforms(o.raw["subject_transforms"]))
+
+	api, deliver := "", ""
+	if ext, ok := o.raw["external"].(map[string]interface{}); ok {
+		api, _ = ext["api"].(string)
+		deliver, _ = ext["deliver"].(string)
+	}
+	ssp.inputField("External API Prefix").SetText(api)
+	ssp.inputField("External Deliver Prefix").SetText(deliver)
+}
+
+func (ssp *StreamSourcesPage) inputField(label string) *tview.InputField {
+	return ssp.form.GetFormItemByLabel(label).(*tview.InputField)
+}
+
+// commitForm validates the form and writes it into the selected origin
+func (ssp *StreamSourcesPage) commitForm() error {
+	if ssp.current < 0 || ssp.current >= len(ssp.origins) {
+		return nil
+	}
+	o := ssp.origins[ssp.current]
+
+	name := strings.TrimSpace(ssp.inputField("Stream Name").GetText())
+	if name == "" {
+		return fmt.Errorf("stream name of the origin is required")
+	}
+	if strings.ContainsAny(name, " .*>") {
+		return fmt.Errorf("invalid stream name %q", name)
+	}
+
+	var startSeq uint64
+	if text := strings.TrimSpace(ssp.inputField("Start Sequence").GetText()); text != "" {
+		seq, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid start sequence: %v", err)
+		}
+		startSeq = seq
+	}
+	startTime := strings.TrimSpace(ssp.inputField("Start Time (RFC3339)").GetText())
+	if startTime != "" {
+		if _, err := time.Parse(time.RFC3339, startTime); err != nil {
+			return fmt.Errorf("invalid start time, expected RFC3339 like 2024-01-02T15:04:05Z")
+		}
+	}
+	if startSeq > 0 && startTime != "" {
+		return fmt.Errorf("use either a start sequence or a start time, not both")
+	}
+
+	filter := strings.TrimSpace(ssp.inputField("Filter Subject").GetText())
+	if filter != "" && !isValidSubject(filter) {
+		return fmt.Errorf("invalid filter subject %q", filter)
+	}
+	transforms, err := parseSubjectTransforms(ssp.inputField("Subject Transforms (src -> dest; ...)").GetText())
+	if err != nil {
+		return err
+	}
+	if filter != "" && len(transforms) > 0 {
+		return fmt.Errorf("use either a filter subject or subject transforms, not both")
+	}
+
+	_, kind := ssp.form.GetFormItemByLabel("Type").(*tview.DropDown).GetCurrentOption()
+	mirror := kind == originTypeMirror
+	if mirror {
+		for i, other := range ssp.origins {
+			if i != ssp.current && other.mirror {
+				return fmt.Errorf("a stream can only have one mirror")
+			}
+		}
+	}
+
+	o.mirror = mirror
+	o.raw["name"] = name
+	setOrDelete(o.raw, "opt_start_seq", startSeq, startSeq > 0)
+	setOrDelete(o.raw, "opt_start_time", startTime, startTime != "")
+	setOrDelete(o.raw, "filter_subject", filter, filter != "")
+	setOrDelete(o.raw, "subject_transforms", transforms, len(transforms) > 0)
+
+	api := strings.TrimSpace(ssp.inputField("External API Prefix").GetText())
+	deliver := strings.TrimSpace(ssp.inputField("External Deliver Prefix").GetText())
+	if api == "" && deliver != "" {
+		return fmt.Errorf("an external deliver prefix needs an API prefix")
+	}
+	external := map[string]interface{}{"api": api}
+	if deliver != "" {
+		external["deliver"] = deliver
+	}
+	setOrDelete(o.raw, "external", external, api != "")
+	return nil
+}
+
+// configUint reads a number from a config map, decoded or set by the form
+func configUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case float64:
+		return uint64(n)
+	case uint64:
+		return n
+	}
+	return 0
+}
+
+func setOrDelete(m map[string]interface{}, key string, value interface{}, set bool) {
+	if set {
+		m[key] = value
+	} else {
+		delete(m, key)
+	}
+}
+
+// parseSubjectTransforms parses "src -> dest; src2 -> dest2". The arrow
+// keeps ">" usable as the wildcard it is in subjects.
+func parseSubjectTransforms(text string) ([]interface{}, error) {
+	transforms := make([]interface{}, 0)
+	for _, part := range strings.Split(text, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		src, dest, found := strings.Cut(part, "->")
+		if !found {
+			return nil, fmt.Errorf("invalid subject transform %q, expected \"src -> dest\"", part)
+		}
+		src, dest = strings.TrimSpace(src), strings.TrimSpace(dest)
+		if !isValidSubject(src) {
+			return nil, fmt.Errorf("invalid subject transform source %q", src)
+		}
+		if dest == "" {
+			return nil, fmt.Errorf("subject transform for %q has no destination", src)
+		}
+		transforms = append(transforms, map[string]interface{}{"src": src, "dest": dest})
+	}
+	return transforms, nil
+}
+
+func formatSubjectTransforms(v interface{}) string {
+	list, _ := v.([]interface{})
+	parts := make([]string, 0, len(list))
+	for _, t := range list {
+		m, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		src, _ := m["src"].(string)
+		dest, _ := m["dest"].(string)
+		parts = append(parts, src+" -> "+dest)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (ssp *StreamSourcesPage) addOrigin() {
+	if err := ssp.commitForm(); err != nil {
+		ssp.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+	for _, o := range ssp.origins {
+		if o.mirror {
+			ssp.notify("A mirror can't have sources, remove the mirror first", 3*time.Second, "error")
+			return
+		}
+	}
+	ssp.origins = append(ssp.origins, &streamOrigin{raw: map[string]interface{}{"name": ""}})
+	ssp.current = -1
+	ssp.refreshList()
+	ssp.originList.SetCurrentItem(len(ssp.origins) - 1)
+	ssp.selectOrigin(len(ssp.origins) - 1)
+	ssp.app.SetFocus(ssp.inputField("Stream Name"))
+}
+
+func (ssp *StreamSourcesPage) removeOrigin() {
+	if ssp.current < 0 || ssp.current >= len(ssp.origins) {
+		return
+	}
+	ssp.origins = append(ssp.origins[:ssp.current], ssp.origins[ssp.current+1:]...)
+	ssp.current = -1
+	ssp.refreshList()
+	if len(ssp.origins) > 0 {
+		ssp.originList.SetCurrentItem(0)
+		ssp.selectOrigin(0)
+	} else {
+		ssp.clearForm()
+	}
+	ssp.app.SetFocus(ssp.originList)
+}
+
+func (ssp *StreamSourcesPage) apply() {
+	if err := ssp.commitForm(); err != nil {
+		ssp.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	var mirror interface{}
+	sources := make([]interface{}, 0)
+	for _, o := range ssp.origins {
+		if o.mirror {
+			mirror = o.raw
+		} else {
+			sources = append(sources, o.raw)
+		}
+	}
+	if mirror != nil && len(sources) > 0 {
+		ssp.notify("A mirror can't have sources as well", 3*time.Second, "error")
+		return
+	}
+
+	ssp.values["mirror"] = mirror
+	if len(sources) > 0 {
+		ssp.values["sources"] = sources
+	} else {
+		ssp.values["sources"] = nil
+	}
+	if mirror != nil {
+		// Mirrors take their subjects from the origin stream
+		ssp.values["subjects"] = nil
+	}
+
+	pages.SwitchToPage("streamAddPage")
+	_, b := pages.GetFrontPage()
+	ssp.onApply(ssp.values)
+	ssp.app.SetFocus(b)
+}
+
+func (ssp *StreamSourcesPage) goBack() {
+	pages.SwitchToPage("streamAddPage")
+	_, b := pages.GetFrontPage()
+	ssp.app.SetFocus(b)
+}
+
+func (ssp *StreamSourcesPage) notify(message string, duration time.Duration, logLevel string) {
+	ssp.footerTxt.SetText(message)
+	ssp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		ssp.footerTxt.SetText("")
+		ssp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/solidpulse/natsdash/ds"
+)
+
+// builtinStreamTemplates are common stream patterns. Values override the
+// defaults of newStreamConfigValues.
+var builtinStreamTemplates = []ds.StreamTemplate{
+	{
+		Name:        "Blank",
+		Description: "Every option with its default value",
+	},
+	{
+		Name:        "Work queue",
+		Description: "Each message is processed once, then removed",
+		Config: map[string]interface{}{
+			"name":        "JOBS",
+			"description": "Work queue, messages are removed once acknowledged",
+			"subjects":    []interface{}{"jobs.>"},
+			"retention":   "workqueue",
+			"max_age":     "7d",
+		},
+	},
+	{
+		Name:        "Event log",
+		Description: "Append only history with a deduplication window",
+		Config: map[string]interface{}{
+			"name":             "EVENTS",
+			"description":      "Event log, publishers set Nats-Msg-Id to deduplicate retries",
+			"subjects":         []interface{}{"events.>"},
+			"max_age":          "30d",
+			"duplicate_window": "1h",
+			"deny_delete":      true,
+		},
+	},
+	{
+		Name:        "Last value per subject",
+		Description: "Keeps only the latest message of every subject",
+		Config: map[string]interface{}{
+			"name":                 "STATE",
+			"description":          "Latest state per subject",
+			"subjects":             []interface{}{"state.>"},
+			"max_age":              "0s",
+			"max_msgs_per_subject": 1,
+			"allow_direct":         true,
+		},
+	},
+	{
+		Name:        "KV-style rollup",
+		Description: "Per subject history that the Nats-Rollup header can collapse",
+		Config: map[string]interface{}{
+			"name":                 "SNAPSHOTS",
+			"description":          "Per subject history with rollups",
+			"subjects":             []interface{}{"snapshots.>"},
+			"max_age":              "0s",
+			"max_msgs_per_subject": 5,
+			"discard":              "new",
+			"allow_rollup_hdrs":    true,
+			"allow_direct":         true,
+			"deny_delete":          true,
+		},
+	},
+	{
+		Name:        "Mirror",
+		Description: "Read only copy of another stream",
+		Config: map[string]interface{}{
+			"name":          "ORDERS_MIRROR",
+			"description":   "Mirror of ORDERS",
+			"subjects":      []interface{}{},
+			"mirror":        map[string]interface{}{"name": "ORDERS"},
+			"mirror_direct": true,
+		},
+	},
+	{
+		Name:        "Sourced aggregate",
+		Description: "Combines the messages of several streams",
+		Config: map[string]interface{}{
+			"name":        "ALL_ORDERS",
+			"description": "Aggregate of the regional order streams",
+			"subjects":    []interface{}{},
+			"sources": []interface{}{
+				map[string]interface{}{"name": "ORDERS_EU"},
+				map[string]interface{}{"name": "ORDERS_US"},
+			},
+		},
+	},
+	{
+		Name:        "Memory cache",
+		Description: "Short lived messages kept in memory",
+		Config: map[string]interface{}{
+			"name":                 "CACHE",
+			"description":          "In memory cache",
+			"subjects":             []interface{}{"cache.>"},
+			"storage":              "memory",
+			"max_age":              "5m",
+			"max_bytes":            64 * 1024 * 1024,
+			"max_msgs_per_subject": 1,
+		},
+	},
+}
+
+// streamTemplateValues returns the editor values of a template
+func streamTemplateValues(tmpl ds.StreamTemplate) map[string]interface{} {
+	values := newStreamConfigValues()
+	for k, v := range tmpl.Config {
+		values[k] = v
+	}
+	return values
+}
+
+// showTemplatePicker lets the user start a new stream from a built-in or a
+// saved template
+func (sp *StreamListPage) showTemplatePicker(onSelect func(values map[string]interface{})) {
+	saved, err := ds.LoadStreamTemplates()
+	if err != nil {
+		sp.notify("Failed to load saved templates: "+err.Error(), 3*time.Second, "error")
+	}
+
+	templates := append(append([]ds.StreamTemplate{}, builtinStreamTemplates...), saved...)
+	items := make([]pickerItem, 0, len(templates))
+	for i, t := range templates {
+		secondary := t.Description
+		if i >= len(builtinStreamTemplates) {
+			secondary = strings.TrimSpace("(saved) " + secondary)
+		}
+		items = append(items, pickerItem{Text: t.Name, Secondary: secondary})
+	}
+	showPickerModal("New Stream From Template", items, func(idx int) {
+		onSelect(streamTemplateValues(templates[idx]))
+	})
+}
+
+// promptSaveTemplate stores the editor content as a template usable in every context
+func (sap *StreamAddPage) promptSaveTemplate() {
+	values, err := sap.editor.values()
+	if err != nil {
+		sap.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+	if _, _, err := streamConfigFromValues(values); err != nil {
+		sap.notify(err.Error(), 3*time.Second, "error")
+		return
+	}
+
+	showPromptModal("Save Stream Template", "Template name: ", "", func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			sap.notify("Template name cannot be empty", 3*time.Second, "error")
+			return
+		}
+		description, _ := values["description"].(string)
+		tmpl := ds.StreamTemplate{Name: name, Description: description, Config: values}
+		if err := ds.SaveStreamTemplate(tmpl); err != nil {
+			sap.notify("Failed to save template: "+err.Error(), 3*time.Second, "error")
+			return
+		}
+		sap.notify("Saved template "+name, 3*time.Second, "info")
+	})
+}
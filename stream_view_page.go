@@ -20,7 +20,9 @@ type StreamViewPage struct {
 	grepFilter    *tview.InputField
 	logView       *tview.TextView
 	subjectName   *tview.InputField
+	headersInput  *tview.InputField
 	txtArea       *tview.TextArea
+	composer      *publishComposer
 	consumer      *nats.Subscription
 	consumerMu    sync.Mutex
 }
@@ -38,13 +40,13 @@ func NewStreamViewPage(app *tview.Application, data *ds.Data) *StreamViewPage {
 
 func (svp *StreamViewPage) setupUI() {
 	// Header setup with simplified controls
-	headerText := "[Esc] Back | [Tab] Next Field | [Alt+Enter] Send"
+	headerText := "[Esc] Back | [Tab] Next Field | [Alt+Enter] Send\n" + publishComposerKeys
 	headerRow := tview.NewFlex()
 	headerRow.SetDirection(tview.FlexColumn)
 	headerRow.SetBorderPadding(1, 0, 1, 1)
 	headerRow.AddItem(createTextView(headerText, tcell.ColorWhite), 0, 1, false)
 	headerRow.SetTitle("Stream View")
-	svp.AddItem(headerRow, 2, 6, false)
+	svp.AddItem(headerRow, 3, 6, false)
 
 	// Filter row
 	filterRow := tview.NewFlex().SetDirection(tview.FlexColumn)
@@ -90,9 +92,22 @@ func (svp *StreamViewPage) setupUI() {
 	svp.subjectName.SetLabel("Target Subject: ")
 	svp.subjectName.SetBorder(true)
 	svp.subjectName.SetDoneFunc(func(key tcell.Key) {
+		svp.app.SetFocus(svp.headersInput)
+	})
+
+	// Headers for publishing
+	svp.headersInput = tview.NewInputField()
+	svp.headersInput.SetLabel("Headers: ")
+	svp.headersInput.SetPlaceholder("Key=value; Other=value")
+	svp.headersInput.SetBorder(true)
+	svp.headersInput.SetDoneFunc(func(key tcell.Key) {
 		svp.app.SetFocus(svp.txtArea)
 	})
-	svp.AddItem(svp.subjectName, 3, 6, false)
+
+	targetRow := tview.NewFlex().SetDirection(tview.FlexColumn)
+	targetRow.AddItem(svp.subjectName, 0, 1, false)
+	targetRow.AddItem(svp.headersInput, 0, 1, false)
+	svp.AddItem(targetRow, 3, 6, false)
 
 	// Message text area
 	svp.txtArea = tview.NewTextArea()
@@ -129,6 +144,8 @@ func (svp *StreamViewPage) setupUI() {
 	svp.AddItem(svp.txtArea, 0, 8, false)
 
 	svp.SetBorderPadding(0, 0, 1, 1)
+
+	svp.composer = newPublishComposer(svp.subjectName, svp.headersInput, svp.txtArea, svp.log)
 }
 
 func (svp *StreamViewPage) setupInputCapture() {
@@ -137,13 +154,14 @@ func (svp *StreamViewPage) setupInputCapture() {
 			svp.goBack()
 			return nil
 		}
-		return event
+		return svp.composer.handleKey(event, svp.Data.CurrCtx.Name)
 	})
 }
 
 func (svp *StreamViewPage) redraw(ctx *ds.Context) {
 	svp.logView.Clear()
 	svp.logView.SetTitle(ctx.LogFilePath)
+	svp.composer.reset(ctx.Name)
 	svp.createTemporaryConsumer()
 	svp.app.SetFocus(svp.filterSubject)
 }
@@ -203,21 +221,26 @@ func (svp *StreamViewPage) publishMessage() {
 		return
 	}
 
-	subject := svp.subjectName.GetText()
-	if subject == "" {
+	if svp.subjectName.GetText() == "" {
 		svp.log("ERROR: Subject cannot be empty")
 		return
 	}
 
-	message := svp.txtArea.GetText()
-	if message == "" {
+	if svp.txtArea.GetText() == "" {
 		svp.log("ERROR: Message cannot be empty")
 		return
 	}
 
-	svp.log("PUB[" + subject + "] " + message)
-	svp.txtArea.SetText("", true)
+	msg, err := svp.composer.buildMsg()
+	if err != nil {
+		svp.log("ERROR: Invalid template: " + err.Error())
+		return
+	}
+	subject := msg.Subject
 
+	if !svp.composer.checkSchema(svp.Data.CurrCtx.Name, msg) {
+		return
+	}
 
 	// Get stream info to check subjects
 	stream, err := js.StreamInfo(svp.streamName)
@@ -242,12 +265,15 @@ func (svp *StreamViewPage) publishMessage() {
 		return
 	}
 
-	_, err = js.Publish(subject, []byte(message))
+	_, err = js.PublishMsg(msg)
 	if err != nil {
 		svp.log("ERROR: Failed to publish message: " + err.Error())
 		return
 	}
 
+	svp.log("PUB[" + subject + "] " + string(msg.Data))
+	svp.composer.recordSend(svp.Data.CurrCtx.Name)
+	svp.txtArea.SetText("", true)
 }
 
 func (svp *StreamViewPage) displayMessage(msg *nats.Msg) {
@@ -260,6 +286,10 @@ func (svp *StreamViewPage) displayMessage(msg *nats.Msg) {
 		return // Skip messages that don't match grep
 	}
 
+	if marker := schemaViolationLine(svp.Data.CurrCtx.Name, msg); marker != "" {
+		text += timestamp + " " + marker + "\n"
+	}
+
 	svp.logView.Write([]byte(text))
 	svp.Data.CurrCtx.LogFile.Write([]byte(text))
 	svp.logView.ScrollToEnd()
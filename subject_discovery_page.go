@@ -0,0 +1,468 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+)
+
+const (
+	discoveryRefreshInterval = time.Second
+	discoverySampleSize      = 1024
+)
+
+// subjectStats is the traffic observed on a single subject
+type subjectStats struct {
+	msgs     uint64
+	bytes    uint64
+	lastSeen time.Time
+	sample   []byte
+	header   nats.Header
+}
+
+// discoveryNode is a token of the observed subject hierarchy
+type discoveryNode struct {
+	token    string
+	subject  string
+	children map[string]*discoveryNode
+	treeNode *tview.TreeNode
+
+	// totals of this subject and below, updated on every refresh
+	msgs      uint64
+	bytes     uint64
+	lastSeen  time.Time
+	msgRate   float64
+	byteRate  float64
+	prevMsgs  uint64
+	prevBytes uint64
+}
+
+// SubjectDiscoveryPage subscribes to a wildcard and shows the observed
+// subjects as a live tree with rates, last seen times and sample payloads
+type SubjectDiscoveryPage struct {
+	*tview.Flex
+	Data          *ds.Data
+	app           *tview.Application
+	wildcardInput *tview.InputField
+	tree          *tview.TreeView
+	detailView    *tview.TextView
+	footerTxt     *tview.TextView
+	sub           *nats.Subscription
+	mu            sync.Mutex
+	stats         map[string]*subjectStats
+	root          *discoveryNode
+	flagged       map[string]bool
+	lastRefresh   time.Time
+	refreshGen    int
+}
+
+func NewSubjectDiscoveryPage(app *tview.Application, data *ds.Data) *SubjectDiscoveryPage {
+	sdp := &SubjectDiscoveryPage{
+		Flex:    tview.NewFlex().SetDirection(tview.FlexRow),
+		app:     app,
+		Data:    data,
+		stats:   make(map[string]*subjectStats),
+		flagged: make(map[string]bool),
+	}
+	sdp.setupUI()
+	sdp.setupInputCapture()
+	return sdp
+}
+
+func (sdp *SubjectDiscoveryPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back\n[/] Wildcard", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Enter] Expand\n[Space] Flag Subject", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[p] Pin Flagged to Subscriptions\n[c] Clear Statistics", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[e] Expand All\n[s] Stop/Start", tcell.ColorWhite), 0, 1, false)
+	sdp.AddItem(headerRow, 4, 0, false)
+
+	// Wildcard to observe
+	sdp.wildcardInput = tview.NewInputField().
+		SetLabel("Discover: ").
+		SetText(">").
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	sdp.wildcardInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			sdp.start()
+		}
+		sdp.app.SetFocus(sdp.tree)
+	})
+	sdp.wildcardInput.SetBorderPadding(0, 0, 1, 1)
+	sdp.AddItem(sdp.wildcardInput, 1, 0, false)
+
+	// Subject tree and details side by side
+	body := tview.NewFlex().SetDirection(tview.FlexColumn)
+	sdp.tree = tview.NewTreeView()
+	sdp.tree.SetBorder(true)
+	sdp.tree.SetTitle("Observed Subjects")
+	sdp.tree.SetBorderPadding(0, 0, 1, 1)
+	sdp.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+	sdp.tree.SetChangedFunc(func(node *tview.TreeNode) {
+		sdp.showDetail()
+	})
+	body.AddItem(sdp.tree, 0, 3, true)
+
+	sdp.detailView = tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWrap(true)
+	sdp.detailView.SetBorder(true)
+	sdp.detailView.SetTitle("Details")
+	sdp.detailView.SetBorderPadding(0, 0, 1, 1)
+	body.AddItem(sdp.detailView, 0, 2, false)
+	sdp.AddItem(body, 0, 1, true)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	sdp.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(sdp.footerTxt, 0, 1, false)
+	sdp.AddItem(footer, 3, 0, false)
+
+	sdp.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (sdp *SubjectDiscoveryPage) setupInputCapture() {
+	sdp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if sdp.wildcardInput.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEsc:
+			sdp.goBack()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case '/':
+				sdp.app.SetFocus(sdp.wildcardInput)
+				return nil
+			case ' ':
+				sdp.toggleFlag()
+				return nil
+			case 'p':
+				sdp.pinFlagged()
+				return nil
+			case 'c':
+				sdp.start()
+				return nil
+			case 's':
+				if sdp.sub != nil {
+					sdp.stop()
+					sdp.notify("Discovery stopped", 3*time.Second, "info")
+				} else {
+					sdp.start()
+				}
+				return nil
+			case 'e':
+				sdp.root.treeNode.ExpandAll()
+				return nil
+			}
+		}
+		return event
+	})
+}
+
+// redraw starts discovering with the current wildcard
+func (sdp *SubjectDiscoveryPage) redraw(ctx *ds.Context) {
+	sdp.start()
+	sdp.app.SetFocus(sdp.tree)
+}
+
+// start clears the statistics and subscribes to the wildcard
+func (sdp *SubjectDiscoveryPage) start() {
+	sdp.stop()
+	wildcard := strings.TrimSpace(sdp.wildcardInput.GetText())
+	if wildcard == "" {
+		wildcard = ">"
+		sdp.wildcardInput.SetText(wildcard)
+	}
+
+	sdp.mu.Lock()
+	sdp.stats = make(map[string]*subjectStats)
+	sdp.mu.Unlock()
+	sdp.root = &discoveryNode{token: wildcard, children: make(map[string]*discoveryNode)}
+	sdp.root.treeNode = tview.NewTreeNode(wildcard).SetReference(sdp.root).SetColor(tcell.ColorYellow)
+	sdp.tree.SetRoot(sdp.root.treeNode).SetCurrentNode(sdp.root.treeNode)
+	sdp.lastRefresh = time.Now()
+
+	sub, err := sdp.Data.CurrCtx.Conn.Subscribe(wildcard, sdp.observe)
+	if err != nil {
+		sdp.notify("Failed to subscribe: "+err.Error(), 5*time.Second, "error")
+		return
+	}
+	// Keep up with busy systems, dropped messages only make the rates less exact
+	sub.SetPendingLimits(1_000_000, 256*1024*1024)
+	sdp.sub = sub
+	sdp.notify("Discovering subjects on "+wildcard, 3*time.Second, "info")
+
+	sdp.refreshGen++
+	gen := sdp.refreshGen
+	go func() {
+		for {
+			time.Sleep(discoveryRefreshInterval)
+			keepGoing := make(chan bool, 1)
+			sdp.app.QueueUpdateDraw(func() {
+				if gen != sdp.refreshGen {
+					keepGoing <- false
+					return
+				}
+				if !isPageVisible("subjectDiscoveryPage") {
+					sdp.stop()
+					keepGoing <- false
+					return
+				}
+				sdp.refresh()
+				keepGoing <- true
+			})
+			if !<-keepGoing {
+				return
+			}
+		}
+	}()
+}
+
+// stop ends the subscription, the statistics stay visible
+func (sdp *SubjectDiscoveryPage) stop() {
+	sdp.refreshGen++
+	if sdp.sub != nil {
+		sdp.sub.Unsubscribe()
+		sdp.sub = nil
+	}
+}
+
+// observe records a message, it runs on the subscription goroutine
+func (sdp *SubjectDiscoveryPage) observe(msg *nats.Msg) {
+	sdp.mu.Lock()
+	defer sdp.mu.Unlock()
+	stats, ok := sdp.stats[msg.Subject]
+	if !ok {
+		stats = &subjectStats{}
+		sdp.stats[msg.Subject] = stats
+	}
+	stats.msgs++
+	stats.bytes += uint64(len(msg.Data))
+	stats.lastSeen = time.Now()
+	sample := msg.Data
+	if len(sample) > discoverySampleSize {
+		sample = sample[:discoverySampleSize]
+	}
+	stats.sample = append(stats.sample[:0], sample...)
+	stats.header = msg.Header
+}
+
+// refresh adds newly observed subjects to the tree and updates the totals
+func (sdp *SubjectDiscoveryPage) refresh() {
+	now := time.Now()
+	elapsed := now.Sub(sdp.lastRefresh).Seconds()
+	sdp.lastRefresh = now
+
+	sdp.mu.Lock()
+	snapshot := make(map[string]subjectStats, len(sdp.stats))
+	for subject, stats := range sdp.stats {
+		snapshot[subject] = subjectStats{msgs: stats.msgs, bytes: stats.bytes, lastSeen: stats.lastSeen}
+	}
+	sdp.mu.Unlock()
+
+	for subject := range snapshot {
+		sdp.addSubject(subject)
+	}
+	sdp.updateNode(sdp.root, snapshot, elapsed)
+	sdp.root.treeNode.SetText(fmt.Sprintf("%s  (%s subjects)  %s", sdp.root.token, humanCount(uint64(len(snapshot))), discoveryNodeStats(sdp.root)))
+	sdp.showDetail()
+}
+
+// addSubject creates the tree nodes of a subject that wasn't seen before
+func (sdp *SubjectDiscoveryPage) addSubject(subject string) {
+	node := sdp.root
+	for i, token := range strings.Split(subject, ".") {
+		child, ok := node.children[token]
+		if !ok {
+			child = &discoveryNode{token: token, children: make(map[string]*discoveryNode)}
+			if i == 0 {
+				child.subject = token
+			} else {
+				child.subject = node.subject + "." + token
+			}
+			child.treeNode = tview.NewTreeNode(token).SetReference(child).SetExpanded(false)
+			node.children[token] = child
+
+			// Keep the children ordered by token
+			children := node.treeNode.GetChildren()
+			idx := sort.Search(len(children), func(i int) bool {
+				return children[i].GetReference().(*discoveryNode).token > token
+			})
+			children = append(children, nil)
+			copy(children[idx+1:], children[idx:])
+			children[idx] = child.treeNode
+			node.treeNode.SetChildren(children)
+		}
+		node = child
+	}
+}
+
+// updateNode sums up the statistics of the subject and its children
+func (sdp *SubjectDiscoveryPage) updateNode(node *discoveryNode, snapshot map[string]subjectStats, elapsed float64) {
+	node.msgs, node.bytes, node.lastSeen = 0, 0, time.Time{}
+	if stats, ok := snapshot[node.subject]; ok && node != sdp.root {
+		node.msgs, node.bytes, node.lastSeen = stats.msgs, stats.bytes, stats.lastSeen
+	}
+	for _, child := range node.children {
+		sdp.updateNode(child, snapshot, elapsed)
+		node.msgs += child.msgs
+		node.bytes += child.bytes
+		if child.lastSeen.After(node.lastSeen) {
+			node.lastSeen = child.lastSeen
+		}
+	}
+	if elapsed > 0 {
+		node.msgRate = float64(node.msgs-node.prevMsgs) / elapsed
+		node.byteRate = float64(node.bytes-node.prevBytes) / elapsed
+	}
+	node.prevMsgs, node.prevBytes = node.msgs, node.bytes
+	if node == sdp.root {
+		return
+	}
+
+	text := node.token + "  " + discoveryNodeStats(node)
+	if len(node.children) > 0 {
+		text = fmt.Sprintf("%s  (%d)  %s", node.token, len(node.children), discoveryNodeStats(node))
+		node.treeNode.SetColor(tcell.ColorGreen)
+	}
+	if sdp.flagged[node.pinSubject()] {
+		text = "* " + text
+		node.treeNode.SetColor(tcell.ColorYellow)
+	} else if len(node.children) == 0 {
+		node.treeNode.SetColor(tcell.ColorWhite)
+	}
+	node.treeNode.SetText(text)
+}
+
+// pinSubject is the subject to subscribe to for this node and its children
+func (n *discoveryNode) pinSubject() string {
+	if len(n.children) > 0 {
+		return n.subject + ".>"
+	}
+	return n.subject
+}
+
+func discoveryNodeStats(node *discoveryNode) string {
+	return fmt.Sprintf("%.1f msg/s  %s/s  %s", node.msgRate, humanBytes(uint64(node.byteRate)), humanAgo(node.lastSeen))
+}
+
+func (sdp *SubjectDiscoveryPage) currentNode() *discoveryNode {
+	treeNode := sdp.tree.GetCurrentNode()
+	if treeNode == nil {
+		return nil
+	}
+	return treeNode.GetReference().(*discoveryNode)
+}
+
+// showDetail shows the totals and the latest payload of the current subject
+func (sdp *SubjectDiscoveryPage) showDetail() {
+	node := sdp.currentNode()
+	if node == nil {
+		sdp.detailView.SetText("")
+		return
+	}
+	var sb strings.Builder
+	if node != sdp.root {
+		sb.WriteString("[yellow]Subject:[-]   " + tview.Escape(node.subject) + "\n")
+	}
+	sb.WriteString("[yellow]Messages:[-]  " + humanCount(node.msgs) + "\n")
+	sb.WriteString("[yellow]Bytes:[-]     " + humanBytes(node.bytes) + "\n")
+	sb.WriteString(fmt.Sprintf("[yellow]Rate:[-]      %.1f msg/s, %s/s\n", node.msgRate, humanBytes(uint64(node.byteRate))))
+	sb.WriteString("[yellow]Last Seen:[-] " + humanAgo(node.lastSeen) + "\n")
+
+	sdp.mu.Lock()
+	stats, ok := sdp.stats[node.subject]
+	var sample []byte
+	var header nats.Header
+	if ok && node != sdp.root {
+		sample = append(sample, stats.sample...)
+		header = stats.header
+	}
+	sdp.mu.Unlock()
+	if ok && node != sdp.root {
+		if len(header) > 0 {
+			keys := make([]string, 0, len(header))
+			for k := range header {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			sb.WriteString("[yellow]Headers:[-]\n")
+			for _, k := range keys {
+				for _, v := range header[k] {
+					sb.WriteString("  " + tview.Escape(k+": "+v) + "\n")
+				}
+			}
+		}
+		sb.WriteString("[yellow]Sample:[-]\n" + tview.Escape(string(sample)))
+	}
+	sdp.detailView.SetText(sb.String())
+}
+
+// toggleFlag marks the current subject for pinning
+func (sdp *SubjectDiscoveryPage) toggleFlag() {
+	node := sdp.currentNode()
+	if node == nil || node == sdp.root {
+		return
+	}
+	subject := node.pinSubject()
+	if sdp.flagged[subject] {
+		delete(sdp.flagged, subject)
+	} else {
+		sdp.flagged[subject] = true
+	}
+	sdp.refresh()
+}
+
+// pinFlagged adds the flagged subjects, or the current one, to the
+// subscriptions of the NATS page
+func (sdp *SubjectDiscoveryPage) pinFlagged() {
+	subjects := make([]string, 0, len(sdp.flagged))
+	for subject := range sdp.flagged {
+		subjects = append(subjects, subject)
+	}
+	if len(subjects) == 0 {
+		node := sdp.currentNode()
+		if node == nil || node == sdp.root {
+			sdp.notify("Flag subjects with Space first", 3*time.Second, "warn")
+			return
+		}
+		subjects = append(subjects, node.pinSubject())
+	}
+	sort.Strings(subjects)
+	sdp.flagged = make(map[string]bool)
+
+	sdp.goBack()
+	_, b := pages.GetFrontPage()
+	b.(*NatsPage).pinSubjects(subjects)
+}
+
+func (sdp *SubjectDiscoveryPage) goBack() {
+	sdp.stop()
+	pages.SwitchToPage("natsPage")
+	_, b := pages.GetFrontPage()
+	sdp.app.SetFocus(b.(*NatsPage).logView)
+}
+
+func (sdp *SubjectDiscoveryPage) notify(message string, duration time.Duration, logLevel string) {
+	sdp.footerTxt.SetText(message)
+	sdp.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		sdp.footerTxt.SetText("")
+		sdp.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+	"github.com/solidpulse/natsdash/ds"
+	"github.com/solidpulse/natsdash/natsutil"
+)
+
+// Search results with at most this many subjects are shown fully expanded
+const subjectExplorerExpandLimit = 200
+
+// subjectNode is a token of the subject hierarchy with the totals below it
+type subjectNode struct {
+	token     string
+	subject   string // full subject up to this token
+	stored    uint64 // messages stored on exactly this subject
+	count     uint64 // messages on this subject and below
+	subjects  int    // distinct subjects on this subject and below
+	children  map[string]*subjectNode
+	populated bool // whether the tree node children were created
+}
+
+// buildSubjectTree groups subjects by token
+func buildSubjectTree(root string, counts map[string]uint64) *subjectNode {
+	tree := &subjectNode{token: root, children: make(map[string]*subjectNode)}
+	for subject, count := range counts {
+		node := tree
+		node.count += count
+		node.subjects++
+		for i, token := range strings.Split(subject, ".") {
+			child, ok := node.children[token]
+			if !ok {
+				child = &subjectNode{token: token, children: make(map[string]*subjectNode)}
+				if i == 0 {
+					child.subject = token
+				} else {
+					child.subject = node.subject + "." + token
+				}
+				node.children[token] = child
+			}
+			node = child
+			node.count += count
+			node.subjects++
+		}
+		node.stored = count
+	}
+	return tree
+}
+
+// sortedChildren orders the children by token
+func (n *subjectNode) sortedChildren() []*subjectNode {
+	children := make([]*subjectNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].token < children[j].token })
+	return children
+}
+
+// filter returns the subject to browse the messages of this node
+func (n *subjectNode) filter() string {
+	if len(n.children) == 0 {
+		return n.subject
+	}
+	if n.subject == "" {
+		return ">"
+	}
+	return n.subject + ".>"
+}
+
+// matchSubject reports whether subject matches a search. Searches with
+// wildcard tokens match like subscriptions, others match case-insensitive
+// substrings.
+func matchSubject(search, subject string) bool {
+	if search == "" {
+		return true
+	}
+	if !strings.ContainsAny(search, "*>") {
+		return strings.Contains(strings.ToLower(subject), strings.ToLower(search))
+	}
+	patternTokens := strings.Split(search, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, p := range patternTokens {
+		if p == ">" {
+			return i < len(subjectTokens)
+		}
+		if i >= len(subjectTokens) || (p != "*" && p != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// SubjectExplorerPage shows the subjects of a stream as a tree with message
+// counts per branch
+type SubjectExplorerPage struct {
+	*tview.Flex
+	Data        *ds.Data
+	app         *tview.Application
+	searchInput *tview.InputField
+	tree        *tview.TreeView
+	detailView  *tview.TextView
+	footerTxt   *tview.TextView
+	streamName  string
+	counts      map[string]uint64
+	loadID      int
+}
+
+func NewSubjectExplorerPage(app *tview.Application, data *ds.Data) *SubjectExplorerPage {
+	sep := &SubjectExplorerPage{
+		Flex: tview.NewFlex().SetDirection(tview.FlexRow),
+		app:  app,
+		Data: data,
+	}
+	sep.setupUI()
+	sep.setupInputCapture()
+	return sep
+}
+
+func (sep *SubjectExplorerPage) setupUI() {
+	// Header
+	headerRow := tview.NewFlex()
+	headerRow.SetDirection(tview.FlexColumn)
+	headerRow.SetBorderPadding(1, 0, 1, 1)
+	headerRow.AddItem(createTextView("[Esc] Back\n[/] Search", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[Enter] Expand/Browse\n[m] Browse Messages", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[l] Last Message\n[r] Reload", tcell.ColorWhite), 0, 1, false)
+	headerRow.AddItem(createTextView("[e] Expand All\n[c] Collapse All", tcell.ColorWhite), 0, 1, false)
+	sep.AddItem(headerRow, 4, 0, false)
+
+	// Search
+	sep.searchInput = tview.NewInputField().
+		SetLabel("Search: ").
+		SetPlaceholder("text or wildcard subject like orders.*.eu").
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	sep.searchInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			sep.searchInput.SetText("")
+		}
+		sep.renderTree()
+		sep.app.SetFocus(sep.tree)
+	})
+	sep.searchInput.SetBorderPadding(0, 0, 1, 1)
+	sep.AddItem(sep.searchInput, 1, 0, false)
+
+	// Subject tree and details side by side
+	body := tview.NewFlex().SetDirection(tview.FlexColumn)
+	sep.tree = tview.NewTreeView()
+	sep.tree.SetBorder(true)
+	sep.tree.SetBorderPadding(0, 0, 1, 1)
+	sep.tree.SetSelectedFunc(sep.selectNode)
+	sep.tree.SetChangedFunc(func(node *tview.TreeNode) {
+		sep.showSummary(node)
+	})
+	body.AddItem(sep.tree, 0, 3, true)
+
+	sep.detailView = tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
+	sep.detailView.SetBorder(true)
+	sep.detailView.SetTitle("Details")
+	sep.detailView.SetBorderPadding(0, 0, 1, 1)
+	body.AddItem(sep.detailView, 0, 2, false)
+	sep.AddItem(body, 0, 1, true)
+
+	// Footer
+	footer := tview.NewFlex()
+	footer.SetBorder(true)
+	sep.footerTxt = createTextView("", tcell.ColorWhite)
+	footer.AddItem(sep.footerTxt, 0, 1, false)
+	sep.AddItem(footer, 3, 0, false)
+
+	sep.SetBorderPadding(0, 0, 1, 1)
+}
+
+func (sep *SubjectExplorerPage) setupInputCapture() {
+	sep.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if sep.searchInput.HasFocus() {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyEsc:
+			sep.goBack()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case '/':
+				sep.app.SetFocus(sep.searchInput)
+				return nil
+			case 'r':
+				sep.redraw(&sep.Data.CurrCtx)
+				return nil
+			case 'm':
+				if node := sep.currentNode(); node != nil {
+					sep.browse(node)
+				}
+				return nil
+			case 'l':
+				if node := sep.currentNode(); node != nil {
+					sep.showLastMsg(node)
+				}
+				return nil
+			case 'e':
+				if root := sep.tree.GetRoot(); root != nil {
+					sep.expandAll(root)
+				}
+				return nil
+			case 'c':
+				if root := sep.tree.GetRoot(); root != nil {
+					root.CollapseAll()
+					root.SetExpanded(true)
+					sep.tree.SetCurrentNode(root)
+				}
+				return nil
+			}
+		}
+		return event
+	})
+}
+
+// open explores the subjects of the stream
+func (sep *SubjectExplorerPage) open(ctx *ds.Context, streamName string) {
+	sep.streamName = streamName
+	sep.searchInput.SetText("")
+	sep.counts = nil
+	sep.renderTree()
+	sep.redraw(ctx)
+}
+
+// redraw loads the subject counts in the background, the request is paged
+// by nats.go so it can take a while for streams with many subjects
+func (sep *SubjectExplorerPage) redraw(ctx *ds.Context) {
+	sep.loadID++
+	id := sep.loadID
+	streamName := sep.streamName
+	sep.tree.SetTitle("Subjects of " + streamName)
+	sep.notify("Loading subjects...", 5*time.Second, "info")
+
+	go func() {
+		counts, err := streamSubjectCounts(ctx.Conn, streamName)
+		sep.app.QueueUpdateDraw(func() {
+			if id != sep.loadID {
+				return // a newer load was started
+			}
+			if err != nil {
+				sep.notify("Failed to load subjects: "+err.Error(), 5*time.Second, "error")
+				return
+			}
+			sep.counts = counts
+			sep.renderTree()
+			sep.notify(fmt.Sprintf("Loaded %s subjects", humanCount(uint64(len(counts)))), 3*time.Second, "info")
+		})
+	}()
+	sep.app.SetFocus(sep.tree)
+}
+
+// streamSubjectCounts returns the number of messages per subject
+func streamSubjectCounts(conn *nats.Conn, streamName string) (map[string]uint64, error) {
+	js, err := conn.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	info, err := js.StreamInfo(streamName, &nats.StreamInfoRequest{SubjectsFilter: ">"})
+	if err != nil {
+		return nil, err
+	}
+	if info.State.Subjects == nil {
+		return map[string]uint64{}, nil
+	}
+	return info.State.Subjects, nil
+}
+
+// renderTree builds the tree of the subjects matching the search
+func (sep *SubjectExplorerPage) renderTree() {
+	search := strings.TrimSpace(sep.searchInput.GetText())
+	matches := make(map[string]uint64)
+	for subject, count := range sep.counts {
+		if matchSubject(search, subject) {
+			matches[subject] = count
+		}
+	}
+
+	tree := buildSubjectTree(sep.streamName, matches)
+	root := sep.newTreeNode(tree)
+	root.SetText(fmt.Sprintf("%s  (%s msgs, %s subjects)", sep.streamName, humanCount(tree.count), humanCount(uint64(tree.subjects))))
+	root.SetColor(tcell.ColorYellow)
+	sep.populate(root)
+	root.SetExpanded(true)
+	sep.tree.SetRoot(root).SetCurrentNode(root)
+
+	if search != "" && len(matches) <= subjectExplorerExpandLimit {
+		sep.expandAll(root)
+	}
+	sep.showSummary(root)
+}
+
+func (sep *SubjectExplorerPage) newTreeNode(node *subjectNode) *tview.TreeNode {
+	text := fmt.Sprintf("%s  (%s msgs)", node.token, humanCount(node.count))
+	if len(node.children) > 0 {
+		text = fmt.Sprintf("%s  (%s msgs, %s subjects)", node.token, humanCount(node.count), humanCount(uint64(node.subjects)))
+	}
+	treeNode := tview.NewTreeNode(text).SetReference(node).SetSelectable(true)
+	if len(node.children) > 0 {
+		treeNode.SetColor(tcell.ColorGreen)
+		treeNode.SetExpanded(false)
+	}
+	return treeNode
+}
+
+// populate creates the children of a tree node on first use so streams with
+// many subjects only build what is looked at
+func (sep *SubjectExplorerPage) populate(treeNode *tview.TreeNode) {
+	node := treeNode.GetReference().(*subjectNode)
+	if node.populated {
+		return
+	}
+	node.populated = true
+	for _, child := range node.sortedChildren() {
+		treeNode.AddChild(sep.newTreeNode(child))
+	}
+}
+
+func (sep *SubjectExplorerPage) expandAll(treeNode *tview.TreeNode) {
+	sep.populate(treeNode)
+	treeNode.SetExpanded(true)
+	for _, child := range treeNode.GetChildren() {
+		sep.expandAll(child)
+	}
+}
+
+// selectNode expands branches and browses the messages of leaves
+func (sep *SubjectExplorerPage) selectNode(treeNode *tview.TreeNode) {
+	node := treeNode.GetReference().(*subjectNode)
+	if len(node.children) == 0 {
+		sep.browse(node)
+		return
+	}
+	sep.populate(treeNode)
+	treeNode.SetExpanded(!treeNode.IsExpanded())
+}
+
+func (sep *SubjectExplorerPage) currentNode() *subjectNode {
+	treeNode := sep.tree.GetCurrentNode()
+	if treeNode == nil {
+		return nil
+	}
+	return treeNode.GetReference().(*subjectNode)
+}
+
+func (sep *SubjectExplorerPage) showSummary(treeNode *tview.TreeNode) {
+	if treeNode == nil {
+		sep.detailView.SetText("")
+		return
+	}
+	node := treeNode.GetReference().(*subjectNode)
+	var sb strings.Builder
+	if node.subject != "" {
+		sb.WriteString("[yellow]Subject:[-]  " + tview.Escape(node.subject) + "\n")
+	}
+	sb.WriteString("[yellow]Messages:[-] " + humanCount(node.count) + "\n")
+	if len(node.children) > 0 {
+		sb.WriteString("[yellow]Subjects:[-] " + humanCount(uint64(node.subjects)) + "\n")
+		sb.WriteString("[yellow]Branches:[-] " + humanCount(uint64(len(node.children))) + "\n")
+		if node.stored > 0 {
+			sb.WriteString(fmt.Sprintf("[yellow]Stored on %s:[-] %s\n", tview.Escape(node.subject), humanCount(node.stored)))
+		}
+	}
+	sb.WriteString("\n[gray]Press l for the last message, m to browse " + tview.Escape(node.filter()) + "[-]")
+	sep.detailView.SetText(sb.String())
+	sep.detailView.ScrollToBeginning()
+}
+
+// showLastMsg shows the latest message of the subject
+func (sep *SubjectExplorerPage) showLastMsg(node *subjectNode) {
+	if node.stored == 0 {
+		sep.notify("No messages are stored on exactly this subject", 3*time.Second, "warn")
+		return
+	}
+	msg, err := natsutil.GetLastMsg(sep.Data.CurrCtx.Conn, sep.streamName, node.subject)
+	if err != nil {
+		sep.notify("Failed to get the last message: "+err.Error(), 3*time.Second, "error")
+		return
+	}
+	sep.detailView.SetText(formatStoredMsg(msg))
+	sep.detailView.ScrollToBeginning()
+}
+
+// browse opens the message browser filtered to the subject of the node
+func (sep *SubjectExplorerPage) browse(node *subjectNode) {
+	pages.SwitchToPage("streamBrowserPage")
+	_, b := pages.GetFrontPage()
+	b.(*StreamBrowserPage).returnPage = "subjectExplorerPage"
+	b.(*StreamBrowserPage).open(&sep.Data.CurrCtx, sep.streamName, node.filter())
+}
+
+func (sep *SubjectExplorerPage) goBack() {
+	sep.loadID++
+	pages.SwitchToPage("streamListPage")
+	_, b := pages.GetFrontPage()
+	b.(*StreamListPage).redraw(&sep.Data.CurrCtx)
+	sep.app.SetFocus(b)
+}
+
+func (sep *SubjectExplorerPage) notify(message string, duration time.Duration, logLevel string) {
+	sep.footerTxt.SetText(message)
+	sep.footerTxt.SetTextColor(getLogLevelColor(logLevel))
+
+	go func() {
+		time.Sleep(duration)
+		sep.footerTxt.SetText("")
+		sep.footerTxt.SetTextColor(tcell.ColorWhite)
+	}()
+}
@@ -1,6 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -32,3 +40,300 @@ func getLogLevelColor(logLevel string) tcell.Color {
 		return tcell.ColorWhite
 	}
 }
+
+// humanBytes formats a byte count using binary units, e.g. "1.5 MiB"
+func humanBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// humanCount formats a count with a metric suffix, e.g. "12.3K"
+func humanCount(n uint64) string {
+	const unit = 1000
+	if n < unit {
+		return strconv.FormatUint(n, 10)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// humanAgo formats the time since t, e.g. "3m ago". Zero times are "never".
+func humanAgo(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// showModal displays content centered on top of the current page and gives it
+// focus. closeModal removes it again and restores the previous focus.
+func showModal(name string, content tview.Primitive, width, height int) {
+	prevFocus := app.GetFocus()
+	modalFocus[name] = prevFocus
+
+	column := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(content, height, 1, true).
+		AddItem(nil, 0, 1, false)
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(column, width, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	pages.AddPage(name, modal, true, true)
+	app.SetFocus(content)
+}
+
+var modalFocus = map[string]tview.Primitive{}
+
+func closeModal(name string) {
+	pages.RemovePage(name)
+	if prev, ok := modalFocus[name]; ok && prev != nil {
+		app.SetFocus(prev)
+	}
+	delete(modalFocus, name)
+}
+
+// showPromptModal asks for a single line of text. onDone is only called when
+// the user confirms with Enter.
+func showPromptModal(title, label, initial string, onDone func(text string)) {
+	input := tview.NewInputField().
+		SetLabel(label).
+		SetText(initial)
+	input.SetBorder(true)
+	input.SetTitle(title)
+	input.SetBorderPadding(1, 1, 1, 1)
+	input.SetDoneFunc(func(key tcell.Key) {
+		text := input.GetText()
+		closeModal("promptModal")
+		if key == tcell.KeyEnter {
+			onDone(text)
+		}
+	})
+	showModal("promptModal", input, 80, 5)
+}
+
+// showConfirmModal shows text (with color tags) and calls onConfirm when the
+// user accepts with Enter or y. Esc or n closes it without doing anything.
+func showConfirmModal(title, text string, onConfirm func()) {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(text)
+	view.SetBorder(true)
+	view.SetTitle(title + "  [Enter/y] Confirm  [Esc/n] Back")
+	view.SetBorderPadding(0, 0, 1, 1)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEnter || (event.Key() == tcell.KeyRune && event.Rune() == 'y'):
+			closeModal("confirmModal")
+			onConfirm()
+			return nil
+		case event.Key() == tcell.KeyEsc || (event.Key() == tcell.KeyRune && event.Rune() == 'n'):
+			closeModal("confirmModal")
+			return nil
+		}
+		return event
+	})
+	height := strings.Count(text, "\n") + 4
+	if height > 30 {
+		height = 30
+	}
+	showModal("confirmModal", view, 100, height)
+}
+
+// pickerItem is an entry of showPickerModal
+type pickerItem struct {
+	Text      string
+	Secondary string
+}
+
+// showPickerModal shows a searchable list. Typing filters the items, Enter
+// selects and Esc cancels. onSelect receives the index into items.
+func showPickerModal(title string, items []pickerItem, onSelect func(idx int)) {
+	layout := tview.NewFlex().SetDirection(tview.FlexRow)
+	layout.SetBorder(true)
+	layout.SetTitle(title)
+
+	search := tview.NewInputField().SetLabel("Search: ")
+	list := tview.NewList().
+		SetHighlightFullLine(true).
+		SetSecondaryTextColor(tcell.ColorGray)
+	visible := make([]int, 0, len(items))
+
+	refill := func(filter string) {
+		list.Clear()
+		visible = visible[:0]
+		filter = strings.ToLower(filter)
+		for i, item := range items {
+			if filter != "" && !strings.Contains(strings.ToLower(item.Text+" "+item.Secondary), filter) {
+				continue
+			}
+			visible = append(visible, i)
+			list.AddItem(item.Text, item.Secondary, 0, nil)
+		}
+	}
+	refill("")
+
+	selectCurrent := func() {
+		if list.GetItemCount() == 0 {
+			return
+		}
+		idx := visible[list.GetCurrentItem()]
+		closeModal("pickerModal")
+		onSelect(idx)
+	}
+
+	search.SetChangedFunc(refill)
+	search.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			list.InputHandler()(event, nil)
+			return nil
+		case tcell.KeyEnter:
+			selectCurrent()
+			return nil
+		case tcell.KeyEsc:
+			closeModal("pickerModal")
+			return nil
+		}
+		return event
+	})
+
+	layout.AddItem(search, 1, 0, true)
+	layout.AddItem(list, 0, 1, false)
+	showModal("pickerModal", layout, 100, 22)
+}
+
+var durationUnitRe = regexp.MustCompile(`^(\d+)([ywd])`)
+
+// parseHumanDuration extends time.ParseDuration with day, week and year
+// units, e.g. "7d", "1y" or "1d12h"
+func parseHumanDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	total := time.Duration(0)
+	rest := s
+	for {
+		m := durationUnitRe.FindStringSubmatch(rest)
+		if m == nil {
+			break
+		}
+		n, _ := strconv.ParseInt(m[1], 10, 64)
+		switch m[2] {
+		case "y":
+			total += time.Duration(n) * 365 * 24 * time.Hour
+		case "w":
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case "d":
+			total += time.Duration(n) * 24 * time.Hour
+		}
+		rest = rest[len(m[0]):]
+	}
+	if rest == "" {
+		return total, nil
+	}
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return total + d, nil
+}
+
+// formatHumanDuration is the inverse of parseHumanDuration, e.g. "7d" or "1h30m"
+func formatHumanDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	day := 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// isPageVisible reports whether the page is shown, possibly below a modal
+func isPageVisible(name string) bool {
+	for _, visible := range pages.GetPageNames(true) {
+		if visible == name {
+			return true
+		}
+	}
+	return false
+}
+
+// editExternally suspends the UI and edits text in $VISUAL or $EDITOR, falling
+// back to vi. The extension of the temp file lets the editor pick a syntax.
+func editExternally(text, extension string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+
+	file, err := os.CreateTemp("", "natsdash-*"+extension)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	var runErr error
+	app.Suspend(func() {
+		cmd := exec.Command(args[0], append(args[1:], file.Name())...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		runErr = cmd.Run()
+	})
+	if runErr != nil {
+		return "", fmt.Errorf("%s failed: %v", args[0], runErr)
+	}
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}